@@ -0,0 +1,140 @@
+package breakr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// CallState is the state of the execution loop of a Call.
+type CallState int
+
+const (
+	// CallRunning is the state of a Call while an attempt is executing.
+	CallRunning CallState = iota
+	// CallCooldown is the state of a Call while it waits before the next
+	// attempt.
+	CallCooldown
+	// CallDone is the state of a Call once its execution loop returned.
+	CallDone
+)
+
+func (s CallState) String() string {
+	switch s {
+	case CallCooldown:
+		return "cooling down"
+	case CallDone:
+		return "done"
+	}
+
+	return "running"
+}
+
+// CallStatus is a snapshot of the progress of a Call.
+type CallStatus struct {
+	// Attempt is the number of the current attempt, starting at 1. Attempt is
+	// 0 if no attempt was made yet.
+	Attempt uint
+	// State is the state of the execution loop.
+	State CallState
+}
+
+// Call is the handle of an execution loop running in the background, see
+// Breakr.ExecuteAsync.
+type Call struct {
+	can context.CancelFunc
+	don chan struct{}
+	err error
+	mut sync.Mutex
+	sta CallStatus
+}
+
+// ExecuteAsync executes act like Breakr.Execute in the background and returns
+// immediately. The returned Call allows to wait for, inspect and cancel the
+// execution loop.
+func (b *Breakr) ExecuteAsync(act func() error) *Call {
+	ctx, can := context.WithCancel(context.Background())
+
+	cal := &Call{
+		can: can,
+		don: make(chan struct{}),
+	}
+
+	go func() {
+		defer can()
+
+		err := b.wrapper(ctx, act, nil, cal)
+		b.sta.Record(err)
+		if err != nil {
+			err = tracer.Mask(err)
+		}
+
+		cal.mut.Lock()
+		cal.err = err
+		cal.sta.State = CallDone
+		cal.mut.Unlock()
+
+		close(cal.don)
+	}()
+
+	return cal
+}
+
+// Cancel stops the execution loop, which then returns Closed. The attempt
+// executing at the moment, if any, is not waited for. Cancel has no effect
+// once the execution loop returned.
+func (c *Call) Cancel() {
+	c.can()
+}
+
+// Done returns a channel that is closed once the execution loop returned.
+func (c *Call) Done() <-chan struct{} {
+	return c.don
+}
+
+// Result returns whether the execution loop returned already, and the error
+// it returned, if any.
+func (c *Call) Result() (bool, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.sta.State == CallDone, c.err
+}
+
+// Status returns a snapshot of the progress of the execution loop.
+func (c *Call) Status() CallStatus {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.sta
+}
+
+// Wait waits for the execution loop to return and returns its error. The
+// error of ctx is returned if ctx is done first, while the execution loop
+// keeps running.
+func (c *Call) Wait(ctx context.Context) error {
+	select {
+	case <-c.don:
+		_, err := c.Result()
+		return err
+	case <-ctx.Done():
+		return tracer.Mask(ctx.Err())
+	}
+}
+
+// update reports the progress of the execution loop. Calls to update on a nil
+// Call are no-ops, so that the execution loop does not need to check whether
+// it runs in the background. Progress reported once the execution loop
+// returned is ignored, since inner policies may still be winding down.
+func (c *Call) update(sta CallState, ano uint) {
+	if c == nil {
+		return
+	}
+
+	c.mut.Lock()
+	if c.sta.State != CallDone {
+		c.sta = CallStatus{Attempt: ano, State: sta}
+	}
+	c.mut.Unlock()
+}
@@ -0,0 +1,129 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/tracer"
+)
+
+func Test_Breakr_ExecuteAsync(t *testing.T) {
+	var testError = &tracer.Error{
+		Kind: "testError",
+	}
+
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Budget: 2,
+				Cooler: time.Second,
+			},
+			Timeout: Timeout{
+				Action: -1,
+			},
+		})
+	}
+
+	blo := make(chan struct{})
+	run := make(chan struct{}, 2)
+
+	var cal *Call
+	{
+		cal = b.ExecuteAsync(func() error {
+			run <- struct{}{}
+			<-blo
+			return tracer.Mask(testError)
+		})
+	}
+
+	<-run
+
+	if !cmp.Equal(CallStatus{Attempt: 1, State: CallRunning}, cal.Status()) {
+		t.Fatalf("sta\n\n%s\n", cmp.Diff(CallStatus{Attempt: 1, State: CallRunning}, cal.Status()))
+	}
+
+	done, err := cal.Result()
+	if done || err != nil {
+		t.Fatalf("expected call to be running")
+	}
+
+	blo <- struct{}{}
+
+	waitFor(t, func() bool {
+		return cal.Status() == CallStatus{Attempt: 1, State: CallCooldown}
+	})
+
+	// Waiting for the call stops once the context given to Wait is done,
+	// while the call keeps running.
+	{
+		ctx, can := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := cal.Wait(ctx)
+		can()
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected context error, got %#v", err)
+		}
+	}
+
+	cal.Cancel()
+
+	select {
+	case <-cal.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected call to return once cancelled")
+	}
+
+	err = cal.Wait(context.Background())
+	if !IsClosed(err) {
+		t.Fatalf("expected Closed, got %#v", err)
+	}
+
+	done, err = cal.Result()
+	if !done || !IsClosed(err) {
+		t.Fatalf("expected call to be done with Closed, got %#v", err)
+	}
+
+	if cal.Status().State != CallDone {
+		t.Fatalf("sta\n\n%s\n", cmp.Diff(CallDone, cal.Status().State))
+	}
+}
+
+func Test_Breakr_ExecuteAsync_Result(t *testing.T) {
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Cooler: -1,
+			},
+		})
+	}
+
+	var cou counter
+
+	var cal *Call
+	{
+		cal = b.ExecuteAsync(func() error {
+			cou.Inc()
+			if cou.Cou() < 3 {
+				return errors.New("test error")
+			}
+			return nil
+		})
+	}
+
+	err := cal.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmp.Equal(CallStatus{Attempt: 3, State: CallDone}, cal.Status()) {
+		t.Fatalf("sta\n\n%s\n", cmp.Diff(CallStatus{Attempt: 3, State: CallDone}, cal.Status()))
+	}
+	if b.Stats().Success != 1 {
+		t.Fatalf("suc\n\n%s\n", cmp.Diff(uint64(1), b.Stats().Success))
+	}
+}
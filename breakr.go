@@ -1,25 +1,50 @@
 package breakr
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/xh3b4sd/tracer"
 )
 
 type Config struct {
-	Failure Failure
-	Limiter Limiter
-	Success Success
-	Timeout Timeout
+	Failure  Failure
+	Limiter  Limiter
+	Logger   Logger
+	Observer Observer
+	Poller   Poller
+	Success  Success
+	Timeout  Timeout
 }
 
 type Breakr struct {
-	fai Failure
 	lim *limiter
-	suc Success
-	tim Timeout
+	log Logger
+	obs Observer
+	pol Poller
+	ret *retry
+	sta *stats
 }
 
+// New returns a breaker composing the standalone policies of the given
+// configuration, which is roughly equivalent to the composition below. The
+// retry policy of Failure is extended by the success budget, the timeout
+// budget and the poller, so that all of them apply to the same execution loop.
+// The retry policy further bounds every attempt to Timeout.Action itself,
+// sharing one timer between attempt timeouts and cooldowns, and executes
+// attempts that can neither time out nor be interrupted synchronously. The
+// deadline of every attempt is further bound to the remaining time of
+// Timeout.Global.
+//
+//	Compose(
+//	    Timeout{Action: Timeout.Global, Closer: Timeout.Closer}.Policy(),
+//	    Failure.Policy(),
+//	    Timeout{Action: Timeout.Action}.Policy(),
+//	    Limiter.Policy(),
+//	)
 func New(config Config) *Breakr {
 	{
 		if config.Failure.Budget == 0 {
@@ -30,10 +55,22 @@ func New(config Config) *Breakr {
 		}
 	}
 
+	{
+		if config.Poller.Consecutive == 0 {
+			config.Poller.Consecutive = 1
+		}
+		if config.Poller.Interval == 0 {
+			config.Poller.Interval = 1 * time.Second
+		}
+	}
+
 	{
 		if config.Success.Budget == 0 {
 			config.Success.Budget = 1
 		}
+		if config.Success.Cooler == 0 {
+			config.Success.Cooler = -1
+		}
 	}
 
 	{
@@ -55,14 +92,32 @@ func New(config Config) *Breakr {
 		if config.Timeout.Cooler == 0 {
 			config.Timeout.Cooler = -1
 		}
+		if config.Timeout.Floor == 0 {
+			config.Timeout.Floor = -1
+		}
 		if config.Timeout.Global == 0 {
 			config.Timeout.Global = -1
 		}
 	}
 
 	b := &Breakr{
-		fai: config.Failure,
 		lim: config.Limiter.New(),
+		log: config.Logger,
+		obs: config.Observer,
+		pol: config.Poller,
+		sta: &stats{},
+	}
+
+	var rec *recoverer
+	if config.Failure.Recover {
+		rec = &recoverer{obs: config.Observer.Panic}
+	}
+
+	b.ret = &retry{
+		fai: config.Failure,
+		log: config.Logger,
+		pol: config.Poller,
+		rec: rec,
 		suc: config.Success,
 		tim: config.Timeout,
 	}
@@ -71,7 +126,8 @@ func New(config Config) *Breakr {
 }
 
 func (b *Breakr) Execute(act func() error) error {
-	err := b.Wrapper(act)()
+	err := b.wrapper(context.Background(), act, nil, nil)
+	b.sta.Record(err)
 	if err != nil {
 		return tracer.Mask(err)
 	}
@@ -79,85 +135,141 @@ func (b *Breakr) Execute(act func() error) error {
 	return nil
 }
 
+// Stats returns a snapshot of the counters tracked by the breaker instance.
+func (b *Breakr) Stats() Stats {
+	return b.sta.Snapshot()
+}
+
+// QueueStats returns a snapshot of the counters tracked by the limiter of the
+// breaker instance per priority class. Priority classes only apply to calls
+// of Breakr.ExecuteContext, see WithPriority.
+func (b *Breakr) QueueStats() map[Priority]QueueStats {
+	return b.lim.QueueStats()
+}
+
 func (b *Breakr) Wrapper(act func() error) func() error {
 	return func() error {
-		var fco uint
-		var sco uint
-		var tco uint
-
-		erc := make(chan error, 1)
-		exe := make(chan struct{}, 1)
-		glo := timeout(b.tim.Global)
-		suc := make(chan struct{}, 1)
-
-		exe <- struct{}{}
-
-		for {
-			select {
-			case <-exe:
-				go func() {
-					err := b.lim.Execute(act)
-					if err != nil {
-						erc <- tracer.Mask(err)
-					} else {
-						suc <- struct{}{}
-					}
-				}()
-			case <-suc:
-				sco++
-				if sco >= b.suc.Budget {
-					return nil
-				}
-
-				exe <- struct{}{}
-			case <-b.tim.Closer:
-				return tracer.Mask(Closed)
-			case <-glo:
-				return tracer.Mask(Passed)
-			case <-timeout(b.tim.Action):
-				tco++
-
-				if tco >= b.tim.Budget {
-					return tracer.Mask(Passed)
-				}
-
-				if b.tim.Cooler != -1 {
-					time.Sleep(b.tim.Cooler)
-				}
-
-				exe <- struct{}{}
-			case err := <-erc:
-				if IsCancel(err) {
-					return tracer.Mask(err)
-				}
-
-				if IsFilled(err) {
-					return tracer.Mask(err)
-				}
-
-				if IsRepeat(err) {
-					// fall through
-				} else {
-					fco++
-					if fco >= b.fai.Budget {
-						return tracer.Mask(err)
-					}
-
-					if b.fai.Cooler != -1 {
-						time.Sleep(b.fai.Cooler)
-					}
-				}
-
-				exe <- struct{}{}
+		err := b.wrapper(context.Background(), act, nil, nil)
+		b.sta.Record(err)
+		return err
+	}
+}
+
+// ExecuteContext executes act like Breakr.Execute, while every attempt
+// receives its own context carrying the deadline of the attempt. The deadline
+// of an attempt is the earliest of Timeout.Action, the remaining time of
+// Timeout.Global and the deadline of ctx. Cancelling ctx stops the execution
+// loop and returns Closed, while ctx expiring returns Passed.
+func (b *Breakr) ExecuteContext(ctx context.Context, act func(ctx context.Context) error) error {
+	err := b.wrapper(ctx, nil, act, nil)
+	b.sta.Record(err)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+// wrapper executes either act, if cta is nil, or cta through the policies of
+// the breaker. The progress of the execution loop is reported to the optional
+// cal. Only the actions of asynchronous attempts are allocated on the heap,
+// so that synchronous attempts do not allocate at all.
+func (b *Breakr) wrapper(ctx context.Context, act func() error, cta func(ctx context.Context) error, cal *Call) (err error) {
+	// The progress is only tracked if anyone is interested in it, so that
+	// attempts on hot paths do not pay for it.
+	var pro *progress
+	if b.log.Handler != nil || cal != nil {
+		pro = &progress{cal: cal, sta: time.Now()}
+		ctx = context.WithValue(ctx, progressKey, pro)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if IsFilled(err) {
+			var fil *FilledError
+			var del time.Duration
+			if errors.As(err, &fil) {
+				del = fil.Delay
 			}
+
+			b.log.emit(EventFilled, pro.attempt(), pro.start(), err, slog.Duration("delay", del))
+		} else {
+			b.log.emit(EventGiveUp, pro.attempt(), pro.start(), err)
 		}
+	}()
+
+	if b.ret.tim.Global != -1 {
+		var can context.CancelFunc
+		ctx, can = context.WithTimeout(ctx, b.ret.tim.Global)
+		defer can()
 	}
+
+	return b.ret.run(ctx, func(ctx context.Context, ano uint, cyc *cycle) error {
+		if cyc == nil {
+			return b.lim.apply(ctx, func(ctx context.Context) error { return b.recovered(ctx, act, cta) })
+		}
+
+		// Only callers of Breakr.ExecuteContext receive the context of the
+		// attempt, which is why only they need its deadline.
+		return b.ret.attempt(ctx, func(ctx context.Context) error {
+			return b.lim.apply(ctx, func(ctx context.Context) error { return b.recovered(ctx, act, cta) })
+		}, ano, cyc, cta != nil)
+	})
 }
 
-func timeout(dur time.Duration) <-chan time.Time {
-	if dur != -1 {
-		return time.After(dur)
+// recovered executes either act, if cta is nil, or cta like recovered.
+func (b *Breakr) recovered(ctx context.Context, act func() error, cta func(ctx context.Context) error) error {
+	if cta != nil {
+		return recovered(ctx, cta)
 	}
 
-	return nil
+	return recovered(ctx, func(context.Context) error { return act() })
+}
+
+// progress tracks the execution loop of a single call, so that log records
+// and the optional Call report the current attempt. Methods of a nil progress
+// are no-ops.
+type progress struct {
+	ano atomic.Uint64
+	cal *Call
+	sta time.Time
+}
+
+func (p *progress) attempt() uint {
+	if p == nil {
+		return 0
+	}
+
+	return uint(p.ano.Load())
+}
+
+func (p *progress) start() time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+
+	return p.sta
+}
+
+func (p *progress) update(sta CallState, ano uint) {
+	if p == nil {
+		return
+	}
+
+	p.ano.Store(uint64(ano))
+	p.cal.update(sta, ano)
+}
+
+// interrupted returns the error of the execution loop for the given context
+// being done. Passed is returned if the context expired, and Closed if the
+// context got cancelled.
+func interrupted(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return tracer.Mask(Passed)
+	}
+
+	return tracer.Mask(Closed)
 }
@@ -11,6 +11,11 @@ import (
 	"github.com/xh3b4sd/tracer"
 )
 
+func Test_Breakr_Interface(t *testing.T) {
+	var _ Interface = New(Config{})
+	var _ ContextExecutor = New(Config{})
+}
+
 func Test_Breakr_Default(t *testing.T) {
 	var testError = &tracer.Error{
 		Kind: "testError",
@@ -974,9 +979,9 @@ func Test_Breakr_Timeout_Repeat_1(t *testing.T) {
 
 				return nil
 			},
-			val: 2, // nil is returned after 180ms, which is within the 5*100ms tolerance
+			val: 7, // the first 3 attempts time out, since their results are discarded
 			mat: func(err error) bool {
-				return errors.Is(err, nil) // Repeat is never called because 3*200ms > 5*100ms
+				return errors.Is(err, nil) // Repeat does not use up the timeout budget of 5
 			},
 		},
 		// case 2
@@ -988,9 +993,9 @@ func Test_Breakr_Timeout_Repeat_1(t *testing.T) {
 
 				return tracer.Mask(Cancel)
 			},
-			val: 3, // the 100ms timeout executes act 3 times within 250ms
+			val: 5, // every attempt times out after 100ms, before returning Cancel
 			mat: func(err error) bool {
-				return errors.Is(err, Cancel) // Cancel is returned after 250ms, which is within the 5*100ms tolerance
+				return errors.Is(err, Passed) // the timeout budget of 5 is used up after 5*100ms
 			},
 		},
 	}
@@ -1231,6 +1236,58 @@ func Test_Breakr_Timeout_Timeout(t *testing.T) {
 	}
 }
 
+func Benchmark_Breakr_Execute_Success(b *testing.B) {
+	testCases := []struct {
+		tim Timeout
+	}{
+		// Case 0 measures the success path of attempts that do not need to be
+		// interrupted, which are executed synchronously.
+		{
+			tim: Timeout{
+				Action: -1,
+			},
+		},
+		// Case 1 measures the success path of attempts bound to the default
+		// action timeout.
+		{
+			tim: Timeout{},
+		},
+		// Case 2 measures the success path of attempts that can be interrupted
+		// using the signal channel.
+		{
+			tim: Timeout{
+				Action: -1,
+				Closer: make(chan struct{}),
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		b.Run(fmt.Sprintf("%03d", i), func(b *testing.B) {
+			var r *Breakr
+			{
+				r = New(Config{
+					Timeout: tc.tim,
+				})
+			}
+
+			act := func() error {
+				return nil
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for j := 0; j < b.N; j++ {
+				err := r.Execute(act)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 type counter struct {
 	cou uint
 	max uint
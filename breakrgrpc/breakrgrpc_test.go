@@ -0,0 +1,356 @@
+package breakrgrpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/breakr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func Test_Client_Unary(t *testing.T) {
+	testCases := []struct {
+		cod []codes.Code
+		exp codes.Code
+		cou int
+	}{
+		// Case 0 ensures that UNAVAILABLE is retried.
+		{
+			cod: []codes.Code{codes.Unavailable, codes.Unavailable, codes.OK},
+			exp: codes.OK,
+			cou: 3,
+		},
+		// Case 1 ensures that RESOURCE_EXHAUSTED is retried.
+		{
+			cod: []codes.Code{codes.ResourceExhausted, codes.OK},
+			exp: codes.OK,
+			cou: 2,
+		},
+		// Case 2 ensures that INVALID_ARGUMENT is not retried.
+		{
+			cod: []codes.Code{codes.InvalidArgument, codes.OK},
+			exp: codes.InvalidArgument,
+			cou: 1,
+		},
+		// Case 3 ensures that the last status is returned once the failure
+		// budget is used up.
+		{
+			cod: []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable, codes.OK},
+			exp: codes.Unavailable,
+			cou: 3,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var hea *testHealth
+			{
+				hea = &testHealth{cod: tc.cod}
+			}
+
+			var cli grpc_health_v1.HealthClient
+			{
+				cli = testClient(t, hea, nil, NewClient(ClientConfig{
+					Breakr: breakr.New(breakr.Config{
+						Failure: breakr.Failure{
+							Budget: 3,
+							Cooler: -1,
+						},
+					}),
+				}))
+			}
+
+			_, err := cli.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			if status.Code(err) != tc.exp {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.exp, status.Code(err)))
+			}
+
+			if hea.Cou() != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, hea.Cou()))
+			}
+		})
+	}
+}
+
+func Test_Client_Unary_Deadline(t *testing.T) {
+	var hea *testHealth
+	{
+		hea = &testHealth{cod: []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}}
+	}
+
+	var bre *breakr.Breakr
+	{
+		bre = breakr.New(breakr.Config{
+			Failure: breakr.Failure{
+				Budget: 3,
+				Cooler: 5 * time.Second,
+			},
+		})
+	}
+
+	var cli grpc_health_v1.HealthClient
+	{
+		cli = testClient(t, hea, nil, NewClient(ClientConfig{
+			Breakr: bre,
+		}))
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	ctx, can := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer can()
+
+	_, err := cli.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("\n\n%s\n", cmp.Diff(codes.DeadlineExceeded, status.Code(err)))
+	}
+
+	if time.Since(sta) > time.Second {
+		t.Fatalf("expected call deadline to be honored")
+	}
+
+	// The breaker must have stopped cooling down once the call deadline
+	// expired, instead of keeping on in the background.
+	if bre.Stats().Failure != 1 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint64(1), bre.Stats().Failure))
+	}
+}
+
+func Test_Client_Stream(t *testing.T) {
+	var hea *testHealth
+	{
+		hea = &testHealth{}
+	}
+
+	var cli grpc_health_v1.HealthClient
+	{
+		cli = testClient(t, hea, nil, NewClient(ClientConfig{
+			Breakr: breakr.New(breakr.Config{
+				Limiter: breakr.Limiter{
+					Budget: 1,
+					Cooler: time.Minute,
+				},
+			}),
+		}))
+	}
+
+	{
+		str, err := cli.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := str.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Fatalf("\n\n%s\n", cmp.Diff(grpc_health_v1.HealthCheckResponse_SERVING, res.Status))
+		}
+	}
+
+	{
+		_, err := cli.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("\n\n%s\n", cmp.Diff(codes.ResourceExhausted, status.Code(err)))
+		}
+	}
+}
+
+func Test_Client_Stream_Timeout(t *testing.T) {
+	var cli *Client
+	{
+		cli = NewClient(ClientConfig{
+			Breakr: breakr.New(breakr.Config{
+				Timeout: breakr.Timeout{
+					Action: 50 * time.Millisecond,
+				},
+			}),
+		})
+	}
+
+	sct := make(chan context.Context, 1)
+
+	// The stream is established only after the attempt timed out.
+	str := func(ctx context.Context, des *grpc.StreamDesc, con *grpc.ClientConn, met string, opt ...grpc.CallOption) (grpc.ClientStream, error) {
+		time.Sleep(200 * time.Millisecond)
+		sct <- ctx
+		return testStream{}, nil
+	}
+
+	_, err := cli.Stream()(context.Background(), &grpc.StreamDesc{}, nil, "/test", str)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("\n\n%s\n", cmp.Diff(codes.DeadlineExceeded, status.Code(err)))
+	}
+
+	// The stream established late must be closed, although the call context
+	// never ends.
+	var ctx context.Context
+	select {
+	case ctx = <-sct:
+	case <-time.After(time.Second):
+		t.Fatalf("expected stream to be established")
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("expected stream to be closed")
+	}
+}
+
+func Test_Server_Unary(t *testing.T) {
+	var hea *testHealth
+	{
+		hea = &testHealth{blo: make(chan struct{})}
+	}
+
+	var cli grpc_health_v1.HealthClient
+	{
+		cli = testClient(t, hea, NewServer(ServerConfig{
+			Limiter: breakr.Limiter{
+				Budget: 2,
+			},
+		}), nil)
+	}
+
+	var wai sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		wai.Add(1)
+		go func() {
+			defer wai.Done()
+			_, _ = cli.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		}()
+	}
+
+	for hea.Cou() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	{
+		_, err := cli.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("\n\n%s\n", cmp.Diff(codes.ResourceExhausted, status.Code(err)))
+		}
+	}
+
+	close(hea.blo)
+	wai.Wait()
+
+	{
+		_, err := cli.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testClient(t *testing.T, hea *testHealth, ser *Server, cli *Client) grpc_health_v1.HealthClient {
+	lis := bufconn.Listen(1024 * 1024)
+
+	var sop []grpc.ServerOption
+	if ser != nil {
+		sop = append(sop, grpc.UnaryInterceptor(ser.Unary()), grpc.StreamInterceptor(ser.Stream()))
+	}
+
+	srv := grpc.NewServer(sop...)
+	grpc_health_v1.RegisterHealthServer(srv, hea)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	dop := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if cli != nil {
+		dop = append(dop, grpc.WithUnaryInterceptor(cli.Unary()), grpc.WithStreamInterceptor(cli.Stream()))
+	}
+
+	con, err := grpc.Dial("bufnet", dop...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = con.Close()
+		srv.Stop()
+	})
+
+	return grpc_health_v1.NewHealthClient(con)
+}
+
+type testHealth struct {
+	grpc_health_v1.UnimplementedHealthServer
+
+	blo chan struct{}
+	cod []codes.Code
+	cou int
+	mut sync.Mutex
+}
+
+func (h *testHealth) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	err := h.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if h.blo != nil {
+		<-h.blo
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (h *testHealth) Cou() int {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return h.cou
+}
+
+func (h *testHealth) Watch(req *grpc_health_v1.HealthCheckRequest, str grpc_health_v1.Health_WatchServer) error {
+	err := h.next()
+	if err != nil {
+		return err
+	}
+
+	return str.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+}
+
+func (h *testHealth) next() error {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	var cod codes.Code
+	if h.cou < len(h.cod) {
+		cod = h.cod[h.cou]
+	}
+
+	h.cou++
+
+	if cod == codes.OK {
+		return nil
+	}
+
+	return status.Error(cod, cod.String())
+}
+
+type testStream struct {
+	grpc.ClientStream
+}
@@ -0,0 +1,218 @@
+package breakrgrpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/xh3b4sd/breakr"
+	"github.com/xh3b4sd/tracer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ClientConfig struct {
+	// Breakr is the breaker applying its policies to every outbound call.
+	// Defaults to breakr.Default().
+	Breakr breakr.Interface
+	// Retryable is the list of status codes considered transient. Calls
+	// failing with any other status code are not retried. Defaults to
+	// codes.Unavailable and codes.ResourceExhausted.
+	Retryable []codes.Code
+}
+
+// Client provides gRPC client interceptors executing outbound calls under the
+// policies of the configured breaker. The deadline of the call context acts
+// as global timeout for all attempts of a call, so that Client returns as
+// soon as the call context expires. Breakers implementing ExecuteContext, like
+// breakr.Breakr, stop retrying at the same time, and bind every unary attempt
+// to the deadline of the attempt.
+type Client struct {
+	bre breakr.Interface
+	ret map[codes.Code]bool
+}
+
+func NewClient(config ClientConfig) *Client {
+	if config.Breakr == nil {
+		config.Breakr = breakr.Default()
+	}
+	if config.Retryable == nil {
+		config.Retryable = []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+	}
+
+	c := &Client{
+		bre: config.Breakr,
+		ret: map[codes.Code]bool{},
+	}
+
+	for _, x := range config.Retryable {
+		c.ret[x] = true
+	}
+
+	return c
+}
+
+func (c *Client) Unary() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, met string, req, rep any, con *grpc.ClientConn, inv grpc.UnaryInvoker, opt ...grpc.CallOption) error {
+		return c.execute(ctx, func(ctx context.Context) error {
+			return inv(ctx, met, req, rep, con, opt...)
+		})
+	}
+}
+
+func (c *Client) Stream() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, des *grpc.StreamDesc, con *grpc.ClientConn, met string, str grpc.Streamer, opt ...grpc.CallOption) (grpc.ClientStream, error) {
+		var don bool
+		var mut sync.Mutex
+		var res *stream
+
+		// Only the establishment of the stream is retried. Errors occurring
+		// while messages are sent or received are left to the caller. Note
+		// that errors of server streaming handlers are only observed when
+		// receiving messages and are therefore never retried here. The stream
+		// is bound to the call context instead of the context of the attempt,
+		// which is cancelled once the attempt returned.
+		err := c.execute(ctx, func(context.Context) error {
+			sct, can := context.WithCancel(ctx)
+
+			cli, err := str(sct, des, con, met, opt...)
+			if err != nil {
+				can()
+				return err
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			// Streams established after the breaker gave up, e.g. because
+			// the attempt timed out, are never handed to the caller, which
+			// is why they are closed right away.
+			if don || res != nil {
+				can()
+				return nil
+			}
+
+			res = &stream{ClientStream: cli, can: can}
+
+			return nil
+		})
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		{
+			don = true
+		}
+
+		if err != nil {
+			if res != nil {
+				res.can()
+			}
+
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+// stream releases the context of a stream once the stream ended, which is
+// the case once receiving a message failed.
+type stream struct {
+	grpc.ClientStream
+	can context.CancelFunc
+}
+
+func (s *stream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.can()
+	}
+
+	return err
+}
+
+// execute runs the given call through the breaker and returns the status
+// error of the last attempt as is. Errors of the breaker itself are mapped
+// onto their closest status code.
+func (c *Client) execute(ctx context.Context, cal func(ctx context.Context) error) error {
+	var las error
+	var mut sync.Mutex
+
+	act := func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			return tracer.Mask(breakr.Cancel)
+		}
+
+		err := cal(ctx)
+
+		{
+			mut.Lock()
+			las = err
+			mut.Unlock()
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if !c.ret[status.Code(err)] {
+			return tracer.Mask(breakr.Cancel)
+		}
+
+		return tracer.Mask(err)
+	}
+
+	var err error
+	{
+		exe, ok := c.bre.(breakr.ContextExecutor)
+		if ok {
+			err = exe.ExecuteContext(ctx, act)
+		} else {
+			erc := make(chan error, 1)
+
+			go func() {
+				erc <- c.bre.Execute(func() error { return act(ctx) })
+			}()
+
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case err = <-erc:
+			}
+		}
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if breakr.IsCancel(err) && las != nil {
+		return las
+	}
+
+	if breakr.IsFilled(err) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	if breakr.IsPassed(err) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	if breakr.IsClosed(err) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	if las != nil {
+		return las
+	}
+
+	return status.Error(codes.Unknown, err.Error())
+}
@@ -0,0 +1,19 @@
+module github.com/xh3b4sd/breakr/breakrgrpc
+
+go 1.21
+
+require (
+	github.com/google/go-cmp v0.6.0
+	github.com/xh3b4sd/breakr v0.1.0
+	github.com/xh3b4sd/tracer v0.11.1
+	google.golang.org/grpc v1.59.0
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	golang.org/x/net v0.14.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+)
@@ -0,0 +1,84 @@
+package breakrgrpc
+
+import (
+	"context"
+
+	"github.com/xh3b4sd/breakr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ServerConfig struct {
+	// Code is the status code returned for calls rejected by the limiter.
+	// Defaults to codes.ResourceExhausted.
+	Code codes.Code
+	// Limiter is the limiter configuration shared by all inbound calls going
+	// through the interceptors of a single Server.
+	Limiter breakr.Limiter
+}
+
+// Server provides gRPC server interceptors shedding inbound load by executing
+// every call through a limiter shared across unary and stream calls.
+type Server struct {
+	cod codes.Code
+	lim interface {
+		ExecuteContext(ctx context.Context, act func() error) error
+	}
+}
+
+func NewServer(config ServerConfig) *Server {
+	if config.Code == codes.OK {
+		config.Code = codes.ResourceExhausted
+	}
+	if config.Limiter.Budget == 0 {
+		config.Limiter.Budget = 3
+	}
+	if config.Limiter.Cooler == 0 {
+		config.Limiter.Cooler = -1
+	}
+
+	s := &Server{
+		cod: config.Code,
+		lim: config.Limiter.New(),
+	}
+
+	return s
+}
+
+func (s *Server) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, inf *grpc.UnaryServerInfo, han grpc.UnaryHandler) (any, error) {
+		var res any
+		var rer error
+
+		err := s.lim.ExecuteContext(ctx, func() error {
+			res, rer = han(ctx, req)
+			return nil
+		})
+		if breakr.IsFilled(err) {
+			return nil, status.Error(s.cod, err.Error())
+		} else if err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		return res, rer
+	}
+}
+
+func (s *Server) Stream() grpc.StreamServerInterceptor {
+	return func(srv any, str grpc.ServerStream, inf *grpc.StreamServerInfo, han grpc.StreamHandler) error {
+		var rer error
+
+		err := s.lim.ExecuteContext(str.Context(), func() error {
+			rer = han(srv, str)
+			return nil
+		})
+		if breakr.IsFilled(err) {
+			return status.Error(s.cod, err.Error())
+		} else if err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		return rer
+	}
+}
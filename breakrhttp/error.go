@@ -0,0 +1,21 @@
+package breakrhttp
+
+import (
+	"errors"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+var Rejected = &tracer.Error{
+	Kind: "rejected",
+	Desc: "Rejected is the error returned by Transport.RoundTrip if the underlying breaker refused to execute the request because its limiter is filled. The request was never sent to the remote server.",
+}
+
+func IsRejected(err error) bool {
+	return errors.Is(err, Rejected)
+}
+
+var statusError = &tracer.Error{
+	Kind: "statusError",
+	Desc: "statusError is the internal error returned by request attempts in order to signal a retryable response status to the underlying breaker.",
+}
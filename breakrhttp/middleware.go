@@ -0,0 +1,276 @@
+package breakrhttp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xh3b4sd/breakr"
+)
+
+type MiddlewareConfig struct {
+	// Key is the optional function mapping inbound requests onto the limiter
+	// budget they consume, e.g. the request path or the caller identity. All
+	// requests share a single budget if Key is not configured.
+	Key func(r *http.Request) string
+	// Limiter is the limiter configuration applied to every key that is not
+	// configured explicitly using Limiters. The limiter of every key persists
+	// its state under its own key, derived from Limiter.Key and the key of
+	// the request, so that keys sharing a breakr.StateStore do not share
+	// their time windows.
+	Limiter breakr.Limiter
+	// Limiters is the optional set of limiter configurations applied to
+	// specific keys as returned by Key.
+	Limiters map[string]breakr.Limiter
+	// Retry is the Retry-After value sent with rejected requests if the
+	// limiter cannot tell when it admits requests again. Defaults to 1s.
+	Retry time.Duration
+	// Status is the response status code sent with rejected requests, usually
+	// either 429 or 503. Defaults to 503.
+	Status int
+}
+
+// MiddlewareStats is a snapshot of the counters tracked for a single key of
+// the middleware.
+type MiddlewareStats struct {
+	// Admitted is the amount of requests handed over to the next handler.
+	Admitted uint64
+	// Rejected is the amount of requests rejected because the limiter of the
+	// key is filled.
+	Rejected uint64
+}
+
+// middlewareStats are the counters tracked for a single key of the
+// middleware.
+type middlewareStats struct {
+	adm atomic.Uint64
+	rej atomic.Uint64
+}
+
+// Middleware sheds inbound load by executing every request through a
+// limiter. Requests rejected by the limiter are answered with the configured
+// status code and a Retry-After header derived from the remaining throttle
+// time of the limiter. Requests failing for any other reason, e.g. because
+// their context ended while waiting in the queue of the limiter, are answered
+// with 503. Keys become idle once none of their requests is in flight and
+// their longest time window passed since their last request. The limiters of
+// idle keys are evicted whenever the amount of tracked keys doubled, so that
+// the memory used by limiters is bounded by the amount of active keys. The
+// counters reported by Stats are kept for every key, so that they never go
+// backwards.
+type Middleware struct {
+	cou map[string]*middlewareStats
+	key func(r *http.Request) string
+	lim breakr.Limiter
+	lis map[string]breakr.Limiter
+	mut sync.Mutex
+	ret time.Duration
+	sta int
+	swe int
+	use map[string]*middlewareKey
+}
+
+func NewMiddleware(config MiddlewareConfig) *Middleware {
+	if config.Key == nil {
+		config.Key = func(r *http.Request) string { return "" }
+	}
+	{
+		config.Limiter = limiterDefaults(config.Limiter)
+	}
+	if config.Retry == 0 {
+		config.Retry = 1 * time.Second
+	}
+	if config.Status == 0 {
+		config.Status = http.StatusServiceUnavailable
+	}
+
+	m := &Middleware{
+		cou: map[string]*middlewareStats{},
+		key: config.Key,
+		lim: config.Limiter,
+		lis: map[string]breakr.Limiter{},
+		ret: config.Retry,
+		sta: config.Status,
+		swe: middlewareSweep,
+		use: map[string]*middlewareKey{},
+	}
+
+	for k, v := range config.Limiters {
+		m.lis[k] = limiterDefaults(v)
+	}
+
+	return m
+}
+
+func (m *Middleware) Handler(nxt http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var key *middlewareKey
+		{
+			key = m.acquire(m.key(r))
+		}
+
+		defer m.release(key)
+
+		err := key.lim.ExecuteContext(r.Context(), func() error {
+			key.cou.adm.Add(1)
+			nxt.ServeHTTP(w, r)
+			return nil
+		})
+		if breakr.IsFilled(err) {
+			key.cou.rej.Add(1)
+
+			w.Header().Set("Retry-After", strconv.Itoa(m.seconds(err)))
+			http.Error(w, http.StatusText(m.sta), m.sta)
+		} else if err != nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// Stats returns a snapshot of the counters tracked for every key, including
+// the keys whose limiters got evicted.
+func (m *Middleware) Stats() map[string]MiddlewareStats {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	sta := map[string]MiddlewareStats{}
+	for k, v := range m.cou {
+		sta[k] = MiddlewareStats{
+			Admitted: v.adm.Load(),
+			Rejected: v.rej.Load(),
+		}
+	}
+
+	return sta
+}
+
+// acquire returns the tracked key for the given name, marking one of its
+// requests as in flight.
+func (m *Middleware) acquire(nam string) *middlewareKey {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	key, ok := m.use[nam]
+	if !ok {
+		if len(m.use) >= m.swe {
+			m.sweep()
+		}
+
+		lim, ok := m.lis[nam]
+		if !ok {
+			lim = m.lim
+		}
+
+		if nam != "" {
+			lim.Key = lim.Key + "/" + nam
+		}
+
+		cou, ok := m.cou[nam]
+		if !ok {
+			cou = &middlewareStats{}
+			m.cou[nam] = cou
+		}
+
+		key = &middlewareKey{cou: cou, lim: lim.New(), win: window(lim)}
+		m.use[nam] = key
+	}
+
+	key.run++
+
+	return key
+}
+
+// release marks one of the requests of the given key as done.
+func (m *Middleware) release(key *middlewareKey) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	key.las = time.Now()
+	key.run--
+}
+
+// sweep evicts the limiters of all idle keys. Idle keys do not carry any
+// limiter state worth keeping, so that evicting them does not change which
+// requests get admitted.
+func (m *Middleware) sweep() {
+	var now time.Time
+	{
+		now = time.Now()
+	}
+
+	for k, v := range m.use {
+		if v.run == 0 && now.Sub(v.las) >= v.win {
+			delete(m.use, k)
+		}
+	}
+
+	m.swe = 2 * len(m.use)
+	if m.swe < middlewareSweep {
+		m.swe = middlewareSweep
+	}
+}
+
+// seconds returns the Retry-After value for the given Filled error in whole
+// seconds, rounded up.
+func (m *Middleware) seconds(err error) int {
+	var dur time.Duration
+	{
+		dur = m.ret
+	}
+
+	var fil *breakr.FilledError
+	if errors.As(err, &fil) && fil.Delay > 0 {
+		dur = fil.Delay
+	}
+
+	return int(math.Ceil(dur.Seconds()))
+}
+
+// window returns the longest time window of the given limiter configuration.
+func window(lim breakr.Limiter) time.Duration {
+	var win time.Duration
+	{
+		win = lim.Cooler
+	}
+
+	for _, w := range lim.Windows {
+		if w.Cooler > win {
+			win = w.Cooler
+		}
+	}
+
+	return win
+}
+
+func limiterDefaults(lim breakr.Limiter) breakr.Limiter {
+	if lim.Budget == 0 {
+		lim.Budget = 3
+	}
+	if lim.Cooler == 0 {
+		lim.Cooler = -1
+	}
+	if lim.Key == "" {
+		lim.Key = "limiter"
+	}
+
+	return lim
+}
+
+// middlewareSweep is the least amount of tracked keys causing idle keys to be
+// evicted.
+const middlewareSweep = 64
+
+type middlewareKey struct {
+	cou *middlewareStats
+	las time.Time
+	lim interface {
+		ExecuteContext(ctx context.Context, act func() error) error
+	}
+	run int
+	win time.Duration
+}
@@ -0,0 +1,204 @@
+package breakrhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/breakr"
+)
+
+func Test_Middleware_Handler(t *testing.T) {
+	var mid *Middleware
+	{
+		mid = NewMiddleware(MiddlewareConfig{
+			Key: func(r *http.Request) string { return r.URL.Path },
+			Limiter: breakr.Limiter{
+				Budget: 2,
+				Cooler: 10 * time.Second,
+			},
+			Limiters: map[string]breakr.Limiter{
+				"/b": {
+					Budget: 1,
+					Cooler: 10 * time.Second,
+				},
+			},
+			Status: http.StatusTooManyRequests,
+		})
+	}
+
+	var han http.Handler
+	{
+		han = mid.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	}
+
+	var cod []int
+	for _, p := range []string{"/a", "/a", "/a", "/b", "/b"} {
+		rec := httptest.NewRecorder()
+		han.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+		cod = append(cod, rec.Code)
+
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "10" {
+			t.Fatalf("\n\n%s\n", cmp.Diff("10", rec.Header().Get("Retry-After")))
+		}
+	}
+
+	{
+		exp := []int{200, 200, 429, 200, 429}
+		if !cmp.Equal(exp, cod) {
+			t.Fatalf("\n\n%s\n", cmp.Diff(exp, cod))
+		}
+	}
+
+	{
+		exp := map[string]MiddlewareStats{
+			"/a": {Admitted: 2, Rejected: 1},
+			"/b": {Admitted: 1, Rejected: 1},
+		}
+		if !cmp.Equal(exp, mid.Stats()) {
+			t.Fatalf("\n\n%s\n", cmp.Diff(exp, mid.Stats()))
+		}
+	}
+}
+
+func Test_Middleware_Handler_Evict(t *testing.T) {
+	testCases := []struct {
+		coo time.Duration
+		mat func(n int) bool
+	}{
+		// Case 0 ensures that idle keys are evicted.
+		{
+			coo: -1,
+			mat: func(n int) bool { return n <= middlewareSweep },
+		},
+		// Case 1 ensures that keys are not evicted within their time window.
+		{
+			coo: 10 * time.Second,
+			mat: func(n int) bool { return n == 1000 },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var mid *Middleware
+			{
+				mid = NewMiddleware(MiddlewareConfig{
+					Key: func(r *http.Request) string { return r.URL.Path },
+					Limiter: breakr.Limiter{
+						Budget: 1,
+						Cooler: tc.coo,
+					},
+				})
+			}
+
+			var han http.Handler
+			{
+				han = mid.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			}
+
+			for j := 0; j < 1000; j++ {
+				rec := httptest.NewRecorder()
+				han.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%d", j), nil))
+
+				if rec.Code != http.StatusOK {
+					t.Fatalf("\n\n%s\n", cmp.Diff(http.StatusOK, rec.Code))
+				}
+			}
+
+			if !tc.mat(len(mid.use)) {
+				t.Fatalf("unexpected amount of keys %d", len(mid.use))
+			}
+
+			// The counters of evicted keys must be kept.
+			if len(mid.Stats()) != 1000 {
+				t.Fatalf("\n\n%s\n", cmp.Diff(1000, len(mid.Stats())))
+			}
+			if mid.Stats()["/0"].Admitted != 1 {
+				t.Fatalf("\n\n%s\n", cmp.Diff(uint64(1), mid.Stats()["/0"].Admitted))
+			}
+		})
+	}
+}
+
+func Test_Middleware_Handler_Error(t *testing.T) {
+	var mid *Middleware
+	{
+		mid = NewMiddleware(MiddlewareConfig{
+			Limiter: breakr.Limiter{
+				Budget: 1,
+				Queue:  1,
+			},
+			Status: http.StatusTooManyRequests,
+		})
+	}
+
+	blo := make(chan struct{})
+	sta := make(chan struct{})
+
+	var han http.Handler
+	{
+		han = mid.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sta <- struct{}{}
+			<-blo
+		}))
+	}
+
+	go func() {
+		han.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+
+	<-sta
+
+	// The second request waits in the queue until its context ends, which is
+	// neither a rejection nor a success.
+	ctx, can := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer can()
+
+	rec := httptest.NewRecorder()
+	han.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	close(blo)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("\n\n%s\n", cmp.Diff(http.StatusServiceUnavailable, rec.Code))
+	}
+	if mid.Stats()[""].Rejected != 0 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint64(0), mid.Stats()[""].Rejected))
+	}
+}
+
+func Test_Middleware_Handler_Store(t *testing.T) {
+	var mid *Middleware
+	{
+		mid = NewMiddleware(MiddlewareConfig{
+			Key: func(r *http.Request) string { return r.URL.Path },
+			Limiter: breakr.Limiter{
+				Budget: 1,
+				Cooler: 10 * time.Second,
+				Store:  breakr.NewFileStore(t.TempDir()),
+			},
+		})
+	}
+
+	var han http.Handler
+	{
+		han = mid.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	}
+
+	var cod []int
+	for _, p := range []string{"/a", "/b", "/a"} {
+		rec := httptest.NewRecorder()
+		han.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+		cod = append(cod, rec.Code)
+	}
+
+	// Keys sharing a store must not share their time window.
+	exp := []int{200, 200, 503}
+	if !cmp.Equal(exp, cod) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(exp, cod))
+	}
+}
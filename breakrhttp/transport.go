@@ -0,0 +1,322 @@
+package breakrhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xh3b4sd/breakr"
+	"github.com/xh3b4sd/tracer"
+)
+
+type TransportConfig struct {
+	// Breakr is the breaker applying its policies to every outbound request.
+	// Defaults to breakr.Default().
+	Breakr breakr.Interface
+	// Delay is the longest Retry-After delay honored before the next attempt.
+	// Retryable responses requesting longer delays are returned to the caller
+	// right away, as are responses requesting delays exceeding the deadline of
+	// the next attempt. Defaults to 10 seconds.
+	Delay time.Duration
+	// Header is the name of the request header carrying an idempotency key.
+	// Requests using non-idempotent methods are only retried if they carry
+	// this header. Defaults to "Idempotency-Key".
+	Header string
+	// Transport is the underlying round tripper used to send requests.
+	// Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// Transport is a http.RoundTripper executing outbound requests under the
+// policies of the configured breaker. Requests are only retried if they are
+// idempotent and if their body can be rewound using http.Request.GetBody.
+// Transport errors, 5xx responses and 429 responses are retried. Retry-After
+// headers of retried responses are honored before the next attempt, counting
+// from the time the response was received, so that the cooldown of the
+// breaker counts towards the delay. Requests of attempts that timed out are
+// cancelled. Once the breaker gives up on a retryable response, the last
+// response is returned to the caller as is.
+type Transport struct {
+	bre breakr.Interface
+	del time.Duration
+	hea string
+	tra http.RoundTripper
+}
+
+func NewTransport(config TransportConfig) *Transport {
+	if config.Breakr == nil {
+		config.Breakr = breakr.Default()
+	}
+	if config.Delay == 0 {
+		config.Delay = 10 * time.Second
+	}
+	if config.Header == "" {
+		config.Header = "Idempotency-Key"
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	t := &Transport{
+		bre: config.Breakr,
+		del: config.Delay,
+		hea: config.Header,
+		tra: config.Transport,
+	}
+
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var rep bool
+	{
+		rep = t.repeatable(req)
+	}
+
+	var att int
+	var don bool
+	var las *http.Response
+	var lae error
+	var mut sync.Mutex
+	var nxt time.Time
+	var suc *http.Response
+
+	act := func(ctx context.Context) error {
+		var cur int
+		var wai time.Duration
+		{
+			mut.Lock()
+			att++
+			cur = att
+			wai = time.Until(nxt)
+			mut.Unlock()
+		}
+
+		// Requests that are not repeatable are never sent twice, which also
+		// applies to attempts following an attempt that timed out.
+		if !rep && cur > 1 {
+			return tracer.Mask(breakr.Cancel)
+		}
+
+		if wai > 0 {
+			dea, ok := ctx.Deadline()
+			if ok && time.Until(dea) < wai {
+				return tracer.Mask(breakr.Cancel)
+			}
+
+			tim := time.NewTimer(wai)
+			select {
+			case <-ctx.Done():
+				tim.Stop()
+				return tracer.Mask(breakr.Cancel)
+			case <-tim.C:
+			}
+		}
+
+		// The request of every attempt is cancelled once the attempt timed
+		// out, while the response body of an attempt that completed in time
+		// can still be read, until it got closed.
+		rct, can := context.WithCancel(req.Context())
+		sto := context.AfterFunc(ctx, can)
+
+		var clo *http.Request
+		{
+			clo = req.Clone(rct)
+		}
+
+		if cur > 1 && req.GetBody != nil {
+			bod, err := req.GetBody()
+			if err != nil {
+				can()
+				return tracer.Maskf(breakr.Cancel, "%s", err.Error())
+			}
+
+			clo.Body = bod
+		}
+
+		res, err := t.tra.RoundTrip(clo)
+		if !sto() {
+			can()
+
+			if res != nil {
+				drain(res)
+			}
+
+			return tracer.Mask(ctx.Err())
+		}
+		if err != nil {
+			can()
+		} else {
+			res.Body = &body{ReadCloser: res.Body, can: can}
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		if don {
+			if res != nil {
+				drain(res)
+			}
+
+			return tracer.Mask(breakr.Cancel)
+		}
+
+		if err != nil {
+			lae = err
+
+			if !rep || req.Context().Err() != nil {
+				return tracer.Mask(breakr.Cancel)
+			}
+
+			return tracer.Mask(err)
+		}
+
+		if rep && retryable(res.StatusCode) {
+			if las != nil {
+				drain(las)
+			}
+
+			del := retryAfter(res)
+
+			lae = nil
+			las = res
+			nxt = time.Now().Add(del)
+
+			if del > t.del {
+				return tracer.Mask(breakr.Cancel)
+			}
+
+			return tracer.Maskf(statusError, "%d", res.StatusCode)
+		}
+
+		if suc != nil {
+			drain(res)
+		} else {
+			suc = res
+		}
+
+		return nil
+	}
+
+	var err error
+	{
+		exe, ok := t.bre.(breakr.ContextExecutor)
+		if ok {
+			err = exe.ExecuteContext(req.Context(), act)
+		} else {
+			err = t.bre.Execute(func() error { return act(req.Context()) })
+		}
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	{
+		don = true
+	}
+
+	if err == nil {
+		if las != nil {
+			drain(las)
+		}
+
+		return suc, nil
+	}
+
+	if suc != nil {
+		drain(suc)
+	}
+
+	if breakr.IsFilled(err) {
+		if las != nil {
+			drain(las)
+		}
+
+		return nil, tracer.Maskf(Rejected, "%s", err.Error())
+	}
+
+	// The last retryable response is returned as long as no transport error
+	// occurred after it, regardless of why the breaker gave up.
+	if las != nil && lae == nil && req.Context().Err() == nil {
+		return las, nil
+	}
+
+	if las != nil {
+		drain(las)
+	}
+
+	if lae != nil {
+		return nil, lae
+	}
+
+	return nil, tracer.Mask(err)
+}
+
+// repeatable returns whether the given request is safe to be sent more than
+// once. Idempotent methods are considered safe, as well as any request
+// carrying an idempotency key. Requests with a body that cannot be rewound are
+// never considered safe.
+func (t *Transport) repeatable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get(t.hea) != ""
+}
+
+// body cancels the request of an attempt once its response body got closed.
+type body struct {
+	io.ReadCloser
+	can context.CancelFunc
+}
+
+func (b *body) Close() error {
+	defer b.can()
+	return b.ReadCloser.Close()
+}
+
+func drain(res *http.Response) {
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+}
+
+func retryable(cod int) bool {
+	return cod == http.StatusTooManyRequests || cod >= 500
+}
+
+// retryAfter returns the delay requested by the given response using the
+// Retry-After header, which may either be given in seconds or as HTTP date.
+func retryAfter(res *http.Response) time.Duration {
+	var hea string
+	{
+		hea = res.Header.Get("Retry-After")
+	}
+
+	if hea == "" {
+		return 0
+	}
+
+	{
+		sec, err := strconv.Atoi(hea)
+		if err == nil && sec > 0 {
+			return time.Duration(sec) * time.Second
+		}
+	}
+
+	{
+		tim, err := http.ParseTime(hea)
+		if err == nil {
+			return time.Until(tim)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,390 @@
+package breakrhttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/breakr"
+)
+
+func Test_Transport_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		met string
+		hea http.Header
+		sta []int
+		cod int
+		cou int
+	}{
+		// Case 0 ensures that idempotent requests are retried on 5xx responses.
+		{
+			met: http.MethodGet,
+			sta: []int{503, 500, 200},
+			cod: 200,
+			cou: 3,
+		},
+		// Case 1 ensures that 429 responses are retried.
+		{
+			met: http.MethodPut,
+			sta: []int{429, 200},
+			cod: 200,
+			cou: 2,
+		},
+		// Case 2 ensures that non-idempotent requests are not retried.
+		{
+			met: http.MethodPost,
+			sta: []int{503, 200},
+			cod: 503,
+			cou: 1,
+		},
+		// Case 3 ensures that non-idempotent requests carrying an idempotency
+		// key are retried.
+		{
+			met: http.MethodPost,
+			hea: http.Header{"Idempotency-Key": []string{"foo"}},
+			sta: []int{503, 200},
+			cod: 200,
+			cou: 2,
+		},
+		// Case 4 ensures that the last response is returned once the failure
+		// budget is used up.
+		{
+			met: http.MethodGet,
+			sta: []int{502, 503, 504, 200},
+			cod: 504,
+			cou: 3,
+		},
+		// Case 5 ensures that non-retryable responses are returned as is.
+		{
+			met: http.MethodGet,
+			sta: []int{404, 200},
+			cod: 404,
+			cou: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var bod []string
+			var cou int
+			var mut sync.Mutex
+
+			var srv *httptest.Server
+			{
+				srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					mut.Lock()
+					defer mut.Unlock()
+
+					b, _ := io.ReadAll(r.Body)
+					bod = append(bod, string(b))
+
+					w.WriteHeader(tc.sta[cou])
+					cou++
+				}))
+				defer srv.Close()
+			}
+
+			var cli *http.Client
+			{
+				cli = &http.Client{
+					Transport: NewTransport(TransportConfig{
+						Breakr: breakr.New(breakr.Config{
+							Failure: breakr.Failure{
+								Budget: 3,
+								Cooler: -1,
+							},
+						}),
+					}),
+				}
+			}
+
+			req, err := http.NewRequest(tc.met, srv.URL, strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.hea {
+				req.Header[k] = v
+			}
+
+			res, err := cli.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.cod {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cod, res.StatusCode))
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			if cou != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, cou))
+			}
+
+			// Every attempt must have sent the full request body, which proves
+			// that the request body got rewound.
+			for _, b := range bod {
+				if b != "body" {
+					t.Fatalf("\n\n%s\n", cmp.Diff("body", b))
+				}
+			}
+		})
+	}
+}
+
+func Test_Transport_RoundTrip_Retry_After(t *testing.T) {
+	var cou int
+	var mut sync.Mutex
+
+	var srv *httptest.Server
+	{
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mut.Lock()
+			defer mut.Unlock()
+
+			if cou == 0 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+
+			cou++
+		}))
+		defer srv.Close()
+	}
+
+	var cli *http.Client
+	{
+		cli = &http.Client{
+			Transport: NewTransport(TransportConfig{
+				Breakr: breakr.New(breakr.Config{
+					Failure: breakr.Failure{
+						Cooler: -1,
+					},
+					Timeout: breakr.Timeout{
+						Action: 5 * time.Second,
+					},
+				}),
+			}),
+		}
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	res, err := cli.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("\n\n%s\n", cmp.Diff(http.StatusOK, res.StatusCode))
+	}
+	if time.Since(sta) < time.Second {
+		t.Fatalf("expected Retry-After to be honored")
+	}
+}
+
+func Test_Transport_RoundTrip_Retry_After_Exceeded(t *testing.T) {
+	var cou int
+	var mut sync.Mutex
+
+	var srv *httptest.Server
+	{
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mut.Lock()
+			defer mut.Unlock()
+
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			cou++
+		}))
+		defer srv.Close()
+	}
+
+	var cli *http.Client
+	{
+		cli = &http.Client{
+			Transport: NewTransport(TransportConfig{
+				Breakr: breakr.New(breakr.Config{
+					Failure: breakr.Failure{
+						Cooler: -1,
+					},
+					Timeout: breakr.Timeout{
+						Action: 500 * time.Millisecond,
+					},
+				}),
+			}),
+		}
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	res, err := cli.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	// The delay requested does not fit into the next attempt, which is why
+	// the response is returned right away.
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("\n\n%s\n", cmp.Diff(http.StatusServiceUnavailable, res.StatusCode))
+	}
+	if time.Since(sta) >= 500*time.Millisecond {
+		t.Fatalf("expected response to be returned right away")
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if cou != 1 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(1, cou))
+	}
+}
+
+func Test_Transport_RoundTrip_Timeout(t *testing.T) {
+	var cou int
+	var mut sync.Mutex
+
+	var srv *httptest.Server
+	{
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mut.Lock()
+			cou++
+			mut.Unlock()
+
+			time.Sleep(300 * time.Millisecond)
+		}))
+		defer srv.Close()
+	}
+
+	var cli *http.Client
+	{
+		cli = &http.Client{
+			Transport: NewTransport(TransportConfig{
+				Breakr: breakr.New(breakr.Config{
+					Failure: breakr.Failure{
+						Cooler: -1,
+					},
+					Timeout: breakr.Timeout{
+						Action: 100 * time.Millisecond,
+						Budget: 3,
+					},
+				}),
+			}),
+		}
+	}
+
+	_, err := cli.Post(srv.URL, "text/plain", strings.NewReader("body"))
+	if !breakr.IsCancel(err) {
+		t.Fatalf("expected Cancel, got %#v", err)
+	}
+
+	// Non-idempotent requests must not be sent again after they timed out.
+	srv.Close()
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if cou != 1 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(1, cou))
+	}
+}
+
+func Test_Transport_RoundTrip_Timeout_Cancel(t *testing.T) {
+	can := make(chan struct{})
+
+	var srv *httptest.Server
+	{
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+				close(can)
+			case <-time.After(2 * time.Second):
+			}
+		}))
+		defer srv.Close()
+	}
+
+	var cli *http.Client
+	{
+		cli = &http.Client{
+			Transport: NewTransport(TransportConfig{
+				Breakr: breakr.New(breakr.Config{
+					Failure: breakr.Failure{
+						Cooler: -1,
+					},
+					Timeout: breakr.Timeout{
+						Action: 100 * time.Millisecond,
+					},
+				}),
+			}),
+		}
+	}
+
+	_, err := cli.Get(srv.URL)
+	if !breakr.IsPassed(err) {
+		t.Fatalf("expected Passed, got %#v", err)
+	}
+
+	// The server must see the request of the attempt that timed out being
+	// cancelled, instead of holding on to it.
+	select {
+	case <-can:
+	case <-time.After(time.Second):
+		t.Fatalf("expected request to be cancelled")
+	}
+}
+
+func Test_Transport_RoundTrip_Rejected(t *testing.T) {
+	var srv *httptest.Server
+	{
+		srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer srv.Close()
+	}
+
+	var cli *http.Client
+	{
+		cli = &http.Client{
+			Transport: NewTransport(TransportConfig{
+				Breakr: breakr.New(breakr.Config{
+					Limiter: breakr.Limiter{
+						Budget: 1,
+						Cooler: time.Minute,
+					},
+				}),
+			}),
+		}
+	}
+
+	{
+		res, err := cli.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	{
+		_, err := cli.Get(srv.URL)
+		if !IsRejected(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+	}
+}
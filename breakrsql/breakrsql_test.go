@@ -0,0 +1,453 @@
+package breakrsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/breakr"
+)
+
+func Test_Connector_Exec(t *testing.T) {
+	var testError = errors.New("test error")
+
+	testCases := []struct {
+		err []error
+		txn bool
+		mat func(err error) bool
+		exe int
+		con int
+	}{
+		// Case 0 ensures that bad connections are replaced and retried.
+		{
+			err: []error{driver.ErrBadConn, driver.ErrBadConn},
+			mat: func(err error) bool { return err == nil },
+			exe: 3,
+			con: 3,
+		},
+		// Case 1 ensures that serialization failures are retried on the same
+		// connection.
+		{
+			err: []error{&testState{sta: "40001"}},
+			mat: func(err error) bool { return err == nil },
+			exe: 2,
+			con: 1,
+		},
+		// Case 2 ensures that errors not considered transient are not retried.
+		{
+			err: []error{testError},
+			mat: func(err error) bool { return errors.Is(err, testError) },
+			exe: 1,
+			con: 1,
+		},
+		// Case 3 ensures that statements within transactions are not retried.
+		{
+			err: []error{&testState{sta: "40001"}},
+			txn: true,
+			mat: func(err error) bool { return Transient(err) },
+			exe: 1,
+			con: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var tes *testConnector
+			{
+				tes = &testConnector{err: tc.err}
+			}
+
+			var db *sql.DB
+			{
+				db = sql.OpenDB(NewConnector(Config{
+					Breakr: breakr.New(breakr.Config{
+						Failure: breakr.Failure{
+							Budget: 3,
+							Cooler: -1,
+						},
+					}),
+				}, tes))
+				defer db.Close()
+			}
+
+			var err error
+			if tc.txn {
+				var txn *sql.Tx
+				txn, err = db.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, err = txn.Exec("foo")
+				_ = txn.Rollback()
+			} else {
+				_, err = db.Exec("foo")
+			}
+
+			if !tc.mat(err) {
+				t.Fatalf("expected error matcher to match: %v", err)
+			}
+
+			if tes.Exe() != tc.exe {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.exe, tes.Exe()))
+			}
+			if tes.Con() != tc.con {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.con, tes.Con()))
+			}
+		})
+	}
+}
+
+func Test_Connector_Exec_Timeout(t *testing.T) {
+	var tes *testConnector
+	{
+		tes = &testConnector{del: 400 * time.Millisecond}
+	}
+
+	var db *sql.DB
+	{
+		db = sql.OpenDB(NewConnector(Config{
+			Breakr: breakr.New(breakr.Config{
+				Failure: breakr.Failure{
+					Budget: 3,
+					Cooler: -1,
+				},
+				Timeout: breakr.Timeout{
+					Action: 50 * time.Millisecond,
+					Budget: 3,
+				},
+			}),
+		}, tes))
+		defer db.Close()
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	_, err := db.Exec("foo")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %#v", err)
+	}
+
+	// The statement that timed out must neither be executed again, nor be
+	// waited for.
+	if time.Since(sta) >= 400*time.Millisecond {
+		t.Fatalf("expected statement not to be waited for")
+	}
+	if tes.Exe() != 1 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(1, tes.Exe()))
+	}
+}
+
+func Test_Connector_Query(t *testing.T) {
+	testCases := []struct {
+		beg bool
+		err []error
+		exe int
+		con int
+	}{
+		// Case 0 ensures that queries on bad connections are replaced and
+		// retried.
+		{
+			err: []error{driver.ErrBadConn, driver.ErrBadConn},
+			exe: 3,
+			con: 3,
+		},
+		// Case 1 ensures that serialization failures of queries are retried on
+		// the same connection.
+		{
+			err: []error{&testState{sta: "40001"}},
+			exe: 2,
+			con: 1,
+		},
+		// Case 2 ensures that transaction starts on bad connections are
+		// replaced and retried.
+		{
+			beg: true,
+			err: []error{driver.ErrBadConn, driver.ErrBadConn},
+			exe: 3,
+			con: 3,
+		},
+		// Case 3 ensures that transaction starts failing with serialization
+		// failures are retried on the same connection.
+		{
+			beg: true,
+			err: []error{&testState{sta: "40001"}},
+			exe: 2,
+			con: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var tes *testConnector
+			{
+				tes = &testConnector{beg: tc.beg, err: tc.err}
+			}
+
+			var db *sql.DB
+			{
+				db = sql.OpenDB(NewConnector(Config{
+					Breakr: breakr.New(breakr.Config{
+						Failure: breakr.Failure{
+							Budget: 3,
+							Cooler: -1,
+						},
+					}),
+				}, tes))
+				defer db.Close()
+			}
+
+			if tc.beg {
+				txn, err := db.Begin()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_ = txn.Rollback()
+			} else {
+				row, err := db.Query("foo")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_ = row.Close()
+			}
+
+			if tes.Exe() != tc.exe {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.exe, tes.Exe()))
+			}
+			if tes.Con() != tc.con {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.con, tes.Con()))
+			}
+		})
+	}
+}
+
+func Test_Connector_Prepare(t *testing.T) {
+	var tes *testConnector
+	{
+		tes = &testConnector{err: []error{driver.ErrBadConn}}
+	}
+
+	var db *sql.DB
+	{
+		db = sql.OpenDB(NewConnector(Config{
+			Breakr: breakr.New(breakr.Config{
+				Failure: breakr.Failure{
+					Budget: 3,
+					Cooler: -1,
+				},
+			}),
+		}, tes))
+		defer db.Close()
+	}
+
+	{
+		db.SetMaxOpenConns(1)
+	}
+
+	var stm *sql.Stmt
+	{
+		var err error
+		stm, err = db.Prepare("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stm.Close()
+	}
+
+	// The connection the statement got prepared on is replaced by the retry
+	// of the bad connection.
+	{
+		_, err := db.Exec("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The statement must not be executed on the replaced connection, but be
+	// prepared again on another one.
+	{
+		_, err := stm.Exec()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tes.Exe() != 3 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(3, tes.Exe()))
+	}
+	if tes.Con() != 3 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(3, tes.Con()))
+	}
+}
+
+type testConnector struct {
+	beg bool
+	con int
+	del time.Duration
+	err []error
+	exe int
+	mut sync.Mutex
+}
+
+func (c *testConnector) Con() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.con
+}
+
+func (c *testConnector) Connect(_ context.Context) (driver.Conn, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.con++
+
+	return &testConn{par: c}, nil
+}
+
+func (c *testConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (c *testConnector) Exe() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.exe
+}
+
+func (c *testConnector) next() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var err error
+	if c.exe < len(c.err) {
+		err = c.err[c.exe]
+	}
+
+	c.exe++
+
+	return err
+}
+
+type testConn struct {
+	clo bool
+	par *testConnector
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	if c.par.beg {
+		err := c.par.next()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &testTx{}, nil
+}
+
+func (c *testConn) Close() error {
+	c.clo = true
+	return nil
+}
+
+func (c *testConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	err := c.par.next()
+	if err != nil {
+		return nil, err
+	}
+
+	// The delay ignores ctx on purpose, like drivers not supporting
+	// cancellation do.
+	time.Sleep(c.par.del)
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{con: c}, nil
+}
+
+func (c *testConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	err := c.par.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return &testRows{}, nil
+}
+
+type testRows struct{}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Columns() []string {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	return io.EOF
+}
+
+type testState struct {
+	sta string
+}
+
+func (s *testState) Error() string {
+	return "test state " + s.sta
+}
+
+func (s *testState) SQLState() string {
+	return s.sta
+}
+
+type testStmt struct {
+	con *testConn
+}
+
+func (s *testStmt) Close() error {
+	return nil
+}
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.con.clo {
+		return nil, errors.New("connection closed")
+	}
+
+	err := s.con.par.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) NumInput() int {
+	return 0
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+type testTx struct{}
+
+func (t *testTx) Commit() error {
+	return nil
+}
+
+func (t *testTx) Rollback() error {
+	return nil
+}
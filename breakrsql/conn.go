@@ -0,0 +1,417 @@
+package breakrsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+)
+
+type conn struct {
+	bad bool
+	cur driver.Conn
+	// gen is the generation of cur, which is incremented whenever cur got
+	// replaced, so that statements prepared on former connections can tell.
+	gen uint
+	mut sync.Mutex
+	par *Connector
+	txn bool
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var res driver.Tx
+
+	// The transaction is bound to the context it got started with, so it is
+	// started using ctx instead of the context of the attempt, which is
+	// cancelled once the attempt returned.
+	err := c.execute(ctx, func(_ context.Context, cur driver.Conn) error {
+		var txn driver.Tx
+		var err error
+
+		bet, ok := cur.(driver.ConnBeginTx)
+		if ok {
+			txn, err = bet.BeginTx(ctx, opts)
+		} else {
+			txn, err = cur.Begin() //nolint:staticcheck
+		}
+		if err != nil {
+			return err
+		}
+
+		res = txn
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	{
+		c.txn = true
+	}
+
+	return &tx{con: c, cur: res}, nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	nvc, ok := c.cur.(driver.NamedValueChecker)
+	if ok {
+		return nvc.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// Close closes the current connection once a statement still running on it
+// returned, without waiting for it. Such statements got abandoned after they
+// timed out.
+func (c *conn) Close() error {
+	if !c.mut.TryLock() {
+		go func() {
+			c.mut.Lock()
+			defer c.mut.Unlock()
+
+			_ = c.cur.Close()
+		}()
+
+		return nil
+	}
+	defer c.mut.Unlock()
+
+	return c.cur.Close()
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+
+	err := c.execute(ctx, func(ctx context.Context, cur driver.Conn) error {
+		exe, ok := cur.(driver.ExecerContext)
+		if !ok {
+			return driver.ErrSkip
+		}
+
+		out, err := exe.ExecContext(ctx, query, args)
+		if err != nil {
+			return err
+		}
+
+		res = out
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// IsValid reports connections still running a statement that got abandoned
+// after it timed out as invalid, so that they are not reused.
+func (c *conn) IsValid() bool {
+	if !c.mut.TryLock() {
+		return false
+	}
+	defer c.mut.Unlock()
+
+	if c.bad {
+		return false
+	}
+
+	val, ok := c.cur.(driver.Validator)
+	if ok {
+		return val.IsValid()
+	}
+
+	return true
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	pin, ok := c.cur.(driver.Pinger)
+	if ok {
+		return pin.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext is not retried, because prepared statements are bound to the
+// connection they got prepared on, which may be replaced during retries.
+// Executing prepared statements bypasses the breaker for the same reason.
+// Statements fail fast with driver.ErrBadConn once the connection they got
+// prepared on got replaced, so that database/sql prepares them again on
+// another connection.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var cur driver.Stmt
+	var err error
+
+	pre, ok := c.cur.(driver.ConnPrepareContext)
+	if ok {
+		cur, err = pre.PrepareContext(ctx, query)
+	} else {
+		cur, err = c.cur.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmt{con: c, cur: cur, gen: c.gen}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var res driver.Rows
+
+	// The rows are bound to the context of the query, so the query is
+	// executed using ctx instead of the context of the attempt, which is
+	// cancelled once the attempt returned.
+	err := c.execute(ctx, func(_ context.Context, cur driver.Conn) error {
+		que, ok := cur.(driver.QueryerContext)
+		if !ok {
+			return driver.ErrSkip
+		}
+
+		out, err := que.QueryContext(ctx, query, args)
+		if err != nil {
+			return err
+		}
+
+		res = out
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if !c.mut.TryLock() {
+		return driver.ErrBadConn
+	}
+	defer c.mut.Unlock()
+
+	if c.bad {
+		return driver.ErrBadConn
+	}
+
+	res, ok := c.cur.(driver.SessionResetter)
+	if ok {
+		return res.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// execute runs the given operation against the current connection. Outside
+// of transactions the operation is executed through the breaker, replacing
+// the current connection before retrying if it turned bad. Within
+// transactions the operation is executed exactly once. The operation receives
+// the context of the attempt, and is never executed again once an attempt
+// timed out.
+func (c *conn) execute(ctx context.Context, ope func(ctx context.Context, cur driver.Conn) error) error {
+	if c.txn {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+
+		return ope(ctx, c.cur)
+	}
+
+	return c.par.execute(ctx, false, func(ctx context.Context) error {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+
+		if c.bad {
+			_ = c.cur.Close()
+
+			cur, err := c.par.con.Connect(ctx)
+			if err != nil {
+				return err
+			}
+
+			c.bad = false
+			c.cur = cur
+			c.gen++
+		}
+
+		err := ope(ctx, c.cur)
+		if broken(err) {
+			c.bad = true
+		}
+
+		return err
+	})
+}
+
+type stmt struct {
+	con *conn
+	cur driver.Stmt
+	gen uint
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	nvc, ok := s.cur.(driver.NamedValueChecker)
+	if ok {
+		return nvc.CheckNamedValue(nv)
+	}
+
+	return s.con.CheckNamedValue(nv)
+}
+
+func (s *stmt) Close() error {
+	return s.cur.Close()
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	var res driver.Result
+
+	err := s.execute(func() error {
+		var err error
+		res, err = s.cur.Exec(args) //nolint:staticcheck
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+
+	err := s.execute(func() error {
+		exe, ok := s.cur.(driver.StmtExecContext)
+		if ok {
+			var err error
+			res, err = exe.ExecContext(ctx, args)
+			return err
+		}
+
+		val, err := values(args)
+		if err != nil {
+			return err
+		}
+
+		res, err = s.cur.Exec(val) //nolint:staticcheck
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.cur.NumInput()
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	var res driver.Rows
+
+	err := s.execute(func() error {
+		var err error
+		res, err = s.cur.Query(args) //nolint:staticcheck
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var res driver.Rows
+
+	err := s.execute(func() error {
+		que, ok := s.cur.(driver.StmtQueryContext)
+		if ok {
+			var err error
+			res, err = que.QueryContext(ctx, args)
+			return err
+		}
+
+		val, err := values(args)
+		if err != nil {
+			return err
+		}
+
+		res, err = s.cur.Query(val) //nolint:staticcheck
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// execute runs the given operation against the prepared statement, unless
+// the connection it got prepared on got replaced in the meantime, in which
+// case driver.ErrBadConn is returned without executing the operation.
+func (s *stmt) execute(ope func() error) error {
+	s.con.mut.Lock()
+	defer s.con.mut.Unlock()
+
+	if s.con.bad || s.con.gen != s.gen {
+		return driver.ErrBadConn
+	}
+
+	err := ope()
+	if broken(err) {
+		s.con.bad = true
+	}
+
+	return err
+}
+
+// values converts the given arguments for drivers not supporting named
+// arguments.
+func values(args []driver.NamedValue) ([]driver.Value, error) {
+	val := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errors.New("breakrsql: driver does not support the use of named parameters")
+		}
+
+		val[i] = a.Value
+	}
+
+	return val, nil
+}
+
+type tx struct {
+	con *conn
+	cur driver.Tx
+}
+
+func (t *tx) Commit() error {
+	defer t.done()
+	return t.cur.Commit()
+}
+
+func (t *tx) Rollback() error {
+	defer t.done()
+	return t.cur.Rollback()
+}
+
+func (t *tx) done() {
+	t.con.txn = false
+}
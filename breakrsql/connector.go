@@ -0,0 +1,168 @@
+package breakrsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+
+	"github.com/xh3b4sd/breakr"
+	"github.com/xh3b4sd/tracer"
+)
+
+type Config struct {
+	// Breakr is the breaker applying its policies to Connect, Exec, Query and
+	// Begin. Breakers implementing ExecuteContext, like breakr.Breakr, hand
+	// every connection attempt and statement the deadline of the attempt.
+	// Statements and transaction starts that timed out are never retried,
+	// because they may have been applied already. Defaults to
+	// breakr.Default().
+	Breakr breakr.Interface
+	// Transient is the optional classifier deciding whether an error is worth
+	// retrying. Errors not considered transient are returned immediately.
+	// Defaults to Transient.
+	Transient func(err error) bool
+}
+
+// Connector is a driver.Connector executing connection attempts, statements
+// and transaction starts under the policies of the configured breaker.
+// Statements executed within a transaction are never retried. Connections
+// that turned bad are replaced transparently before retrying, as long as they
+// are not used by a transaction. Prepared statements are executed without the
+// breaker, since they are bound to the connection they got prepared on. They
+// fail with driver.ErrBadConn once that connection got replaced, so that
+// database/sql prepares them again on another connection.
+type Connector struct {
+	bre breakr.Interface
+	con driver.Connector
+	tra func(err error) bool
+}
+
+func NewConnector(config Config, con driver.Connector) *Connector {
+	if config.Breakr == nil {
+		config.Breakr = breakr.Default()
+	}
+	if config.Transient == nil {
+		config.Transient = Transient
+	}
+
+	c := &Connector{
+		bre: config.Breakr,
+		con: con,
+		tra: config.Transient,
+	}
+
+	return c
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cur, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{cur: cur, par: c}, nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return c.con.Driver()
+}
+
+func (c *Connector) connect(ctx context.Context) (driver.Conn, error) {
+	var res driver.Conn
+
+	err := c.execute(ctx, true, func(ctx context.Context) error {
+		cur, err := c.con.Connect(ctx)
+		if err != nil {
+			return err
+		}
+
+		res = cur
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// execute runs the given operation through the breaker and returns the error
+// of the last attempt as is, because database/sql relies on comparing driver
+// errors like driver.ErrSkip by identity. The operation receives the context
+// of the attempt if the breaker supports it, and ctx otherwise. Operations
+// that are not repeatable are never executed again once an attempt timed
+// out, regardless of whether the timed out attempt returned already.
+func (c *Connector) execute(ctx context.Context, rep bool, ope func(ctx context.Context) error) error {
+	var exp bool
+	var las error
+	var mut sync.Mutex
+	var run sync.Mutex
+
+	act := func(ctx context.Context) error {
+		if rep {
+			run.Lock()
+		} else if !run.TryLock() {
+			// The previous attempt is still running, which means that the
+			// breaker abandoned it after it timed out.
+			mut.Lock()
+			las = context.DeadlineExceeded
+			mut.Unlock()
+
+			return tracer.Mask(breakr.Cancel)
+		}
+		defer run.Unlock()
+
+		mut.Lock()
+		if exp {
+			mut.Unlock()
+			return tracer.Mask(breakr.Cancel)
+		}
+		mut.Unlock()
+
+		err := ope(ctx)
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		las = err
+
+		if err == nil {
+			return nil
+		}
+
+		if !rep && ctx.Err() != nil {
+			exp = true
+			return tracer.Mask(breakr.Cancel)
+		}
+
+		if !c.tra(err) {
+			return tracer.Mask(breakr.Cancel)
+		}
+
+		return tracer.Mask(err)
+	}
+
+	var err error
+	{
+		exe, ok := c.bre.(breakr.ContextExecutor)
+		if ok {
+			err = exe.ExecuteContext(ctx, act)
+		} else {
+			err = c.bre.Execute(func() error { return act(ctx) })
+		}
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if err == nil {
+		return nil
+	}
+
+	if las != nil {
+		return las
+	}
+
+	return err
+}
@@ -0,0 +1,58 @@
+package breakrsql
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// Driver is a driver.Driver opening connections that behave like the ones
+// created by Connector.
+type Driver struct {
+	con Config
+	drv driver.Driver
+}
+
+func NewDriver(config Config, drv driver.Driver) *Driver {
+	d := &Driver{
+		con: config,
+		drv: drv,
+	}
+
+	return d
+}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	con, err := d.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return con.Connect(context.Background())
+}
+
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	dct, ok := d.drv.(driver.DriverContext)
+	if ok {
+		con, err := dct.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewConnector(d.con, con), nil
+	}
+
+	return NewConnector(d.con, &dsnConnector{drv: d.drv, nam: name}), nil
+}
+
+type dsnConnector struct {
+	drv driver.Driver
+	nam string
+}
+
+func (d *dsnConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return d.drv.Open(d.nam)
+}
+
+func (d *dsnConnector) Driver() driver.Driver {
+	return d.drv
+}
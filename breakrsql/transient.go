@@ -0,0 +1,34 @@
+package breakrsql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"syscall"
+)
+
+// Transient is the default classifier deciding whether an error is worth
+// retrying. Bad connections, connection resets, serialization failures and
+// deadlocks are considered transient. Serialization failures and deadlocks
+// are detected using the SQLSTATE codes 40001 and 40P01 of errors
+// implementing SQLState() string, as done by common Postgres drivers.
+func Transient(err error) bool {
+	if broken(err) {
+		return true
+	}
+
+	var sta interface{ SQLState() string }
+	if errors.As(err, &sta) {
+		switch sta.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+
+	return false
+}
+
+// broken returns whether the given error renders the underlying connection
+// unusable, so that it has to be replaced before retrying.
+func broken(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,48 @@
+package breakrtest
+
+import (
+	"errors"
+	"testing"
+)
+
+// AssertAttempts fails the test if the call at index cal recorded by rec did
+// not execute the action exp times.
+func AssertAttempts(t testing.TB, rec *Recorder, cal int, exp int) {
+	t.Helper()
+
+	var all []Call
+	{
+		all = rec.Calls()
+	}
+
+	if cal >= len(all) {
+		t.Fatalf("expected call %d to be recorded, got %d call(s)", cal, len(all))
+	}
+
+	if len(all[cal].Attempts) != exp {
+		t.Fatalf("expected call %d to make %d attempt(s), got %d", cal, exp, len(all[cal].Attempts))
+	}
+}
+
+// AssertCalls fails the test if rec did not record exp calls.
+func AssertCalls(t testing.TB, rec *Recorder, exp int) {
+	t.Helper()
+
+	if len(rec.Calls()) != exp {
+		t.Fatalf("expected %d call(s), got %d", exp, len(rec.Calls()))
+	}
+}
+
+// AssertError fails the test if err does not match exp according to
+// errors.Is. A nil exp requires err to be nil.
+func AssertError(t testing.TB, err error, exp error) {
+	t.Helper()
+
+	if exp == nil && err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !errors.Is(err, exp) {
+		t.Fatalf("expected error matching %v, got %v", exp, err)
+	}
+}
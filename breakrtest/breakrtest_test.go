@@ -0,0 +1,77 @@
+package breakrtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xh3b4sd/breakr"
+)
+
+func Test_Breakrtest_Interface(t *testing.T) {
+	var _ breakr.Interface = NewRecorder(nil)
+	var _ breakr.Interface = NewScripted()
+}
+
+func Test_Breakrtest_Scripted(t *testing.T) {
+	var rec *Recorder
+	{
+		rec = NewRecorder(NewScripted(
+			Step{Attempts: 2, Error: breakr.Passed},
+			Step{Attempts: -1, Error: breakr.Filled},
+		))
+	}
+
+	var cou int
+
+	act := func() error {
+		cou++
+		return fmt.Errorf("test error")
+	}
+
+	AssertError(t, rec.Execute(act), breakr.Passed)
+	AssertError(t, rec.Execute(act), breakr.Filled)
+
+	if !breakr.IsPassed(rec.Calls()[0].Error) {
+		t.Fatalf("expected error matcher to match")
+	}
+
+	// Once all steps are consumed the action is executed exactly once.
+	if rec.Execute(act) == nil {
+		t.Fatalf("expected action error")
+	}
+
+	AssertCalls(t, rec, 3)
+	AssertAttempts(t, rec, 0, 2)
+	AssertAttempts(t, rec, 1, 0)
+	AssertAttempts(t, rec, 2, 1)
+
+	if cou != 3 {
+		t.Fatalf("expected %d got %d", 3, cou)
+	}
+}
+
+func Test_Breakrtest_Recorder(t *testing.T) {
+	var rec *Recorder
+	{
+		rec = NewRecorder(breakr.New(breakr.Config{
+			Failure: breakr.Failure{
+				Budget: 3,
+				Cooler: -1,
+			},
+		}))
+	}
+
+	var cou int
+
+	err := rec.Execute(func() error {
+		cou++
+		if cou < 3 {
+			return fmt.Errorf("test error")
+		}
+		return nil
+	})
+
+	AssertError(t, err, nil)
+	AssertCalls(t, rec, 1)
+	AssertAttempts(t, rec, 0, 3)
+}
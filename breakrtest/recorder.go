@@ -0,0 +1,100 @@
+package breakrtest
+
+import (
+	"sync"
+
+	"github.com/xh3b4sd/breakr"
+	"github.com/xh3b4sd/tracer"
+)
+
+// Call is the record of a single call to Recorder.Execute or of a single
+// execution of a function returned by Recorder.Wrapper.
+type Call struct {
+	// Attempts contains the error returned by every execution of the action in
+	// the order the executions completed.
+	Attempts []error
+	// Error is the error returned by the recorded breaker.
+	Error error
+}
+
+// Recorder is a breakr.Interface recording every call and every attempt
+// made by the wrapped breaker.
+type Recorder struct {
+	bre breakr.Interface
+	cal []*Call
+	mut sync.Mutex
+}
+
+// NewRecorder returns a Recorder wrapping the given breaker. The Recorder
+// wraps breakr.NewSingle() if bre is nil.
+func NewRecorder(bre breakr.Interface) *Recorder {
+	if bre == nil {
+		bre = breakr.NewSingle()
+	}
+
+	r := &Recorder{
+		bre: bre,
+	}
+
+	return r
+}
+
+// Calls returns a copy of all calls recorded so far.
+func (r *Recorder) Calls() []Call {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	var cal []Call
+	for _, c := range r.cal {
+		cal = append(cal, Call{
+			Attempts: append([]error(nil), c.Attempts...),
+			Error:    c.Error,
+		})
+	}
+
+	return cal
+}
+
+func (r *Recorder) Execute(act func() error) error {
+	err := r.Wrapper(act)()
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *Recorder) Wrapper(act func() error) func() error {
+	return func() error {
+		var cal *Call
+		{
+			cal = &Call{}
+		}
+
+		{
+			r.mut.Lock()
+			r.cal = append(r.cal, cal)
+			r.mut.Unlock()
+		}
+
+		err := r.bre.Wrapper(func() error {
+			err := act()
+
+			{
+				r.mut.Lock()
+				cal.Attempts = append(cal.Attempts, err)
+				r.mut.Unlock()
+			}
+
+			return err
+		})()
+
+		{
+			r.mut.Lock()
+			cal.Error = err
+			r.mut.Unlock()
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,84 @@
+package breakrtest
+
+import (
+	"sync"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// Step is the pre-programmed outcome of a single call to Scripted.Execute.
+type Step struct {
+	// Attempts is the amount of times the action is executed during the call.
+	// Use -1 in order to not execute the action at all, e.g. in order to
+	// simulate breakr.Filled. Defaults to 1.
+	Attempts int
+	// Error is the error returned by the call, regardless of the error returned
+	// by the action, e.g. breakr.Passed. The error returned by the last
+	// execution of the action is returned if Error is nil.
+	Error error
+}
+
+// Scripted is a breakr.Interface whose calls follow pre-programmed steps,
+// without relying on any timing. Every call consumes the next step. Once all
+// steps are consumed, every call executes the action exactly once like
+// breakr.Single. The example below simulates two attempts that ended with
+// breakr.Passed, followed by a call rejected with breakr.Filled.
+//
+//	NewScripted(
+//	    Step{Attempts: 2, Error: breakr.Passed},
+//	    Step{Attempts: -1, Error: breakr.Filled},
+//	)
+type Scripted struct {
+	mut sync.Mutex
+	ste []Step
+}
+
+func NewScripted(ste ...Step) *Scripted {
+	s := &Scripted{
+		ste: ste,
+	}
+
+	return s
+}
+
+func (s *Scripted) Execute(act func() error) error {
+	err := s.Wrapper(act)()
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *Scripted) Wrapper(act func() error) func() error {
+	return func() error {
+		var ste Step
+		{
+			s.mut.Lock()
+			if len(s.ste) != 0 {
+				ste = s.ste[0]
+				s.ste = s.ste[1:]
+			}
+			s.mut.Unlock()
+		}
+
+		if ste.Attempts == 0 {
+			ste.Attempts = 1
+		}
+
+		var err error
+		for i := 0; i < ste.Attempts; i++ {
+			err = act()
+		}
+
+		if ste.Error != nil {
+			return tracer.Mask(ste.Error)
+		}
+
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		return nil
+	}
+}
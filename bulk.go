@@ -0,0 +1,156 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+type Bulk struct {
+	// Budget is the optional amount of failed attempts shared across all
+	// actions of a single call to Breakr.ExecuteAll. Once the shared budget is
+	// used up, no further attempts are made and all pending actions return
+	// Cancel alongside their last error, if any. Actions returning Pending do
+	// not use up the shared budget, since they did not fail. Defaults to 0.
+	// Disabled with 0, in which case every action consumes its own
+	// Failure.Budget.
+	Budget uint
+	// Concurrency is the maximum amount of actions executed at the same time.
+	// Concurrency is capped at Limiter.Budget so that the actions of a single
+	// call to Breakr.ExecuteAll do not fill the limiter themselves. Defaults
+	// to Limiter.Budget.
+	Concurrency uint
+	// Global is the amount of time after which Breakr.ExecuteAll stops
+	// executing actions and returns. All actions share a single deadline, so
+	// that the execution loops of actions still in flight stop once it
+	// expires, like they do for Breakr.ExecuteContext. Actions that did not
+	// complete in time return Passed. Defaults to -1. Disabled with -1.
+	Global time.Duration
+}
+
+// ExecuteAll executes every action of acts through the breaker, while at most
+// Bulk.Concurrency actions are executed at the same time. The returned slice
+// contains the result of every action at the index of the action in acts.
+// Every action keeps the semantics of Breakr.Execute, e.g. actions rejected by
+// the limiter return Filled.
+func (b *Breakr) ExecuteAll(acts []func() error, bulk Bulk) []error {
+	{
+		if bulk.Concurrency == 0 || bulk.Concurrency > b.lim.Budget() {
+			bulk.Concurrency = b.lim.Budget()
+		}
+		if bulk.Global == 0 {
+			bulk.Global = -1
+		}
+	}
+
+	var ctx context.Context
+	var can context.CancelFunc
+	if bulk.Global != -1 {
+		ctx, can = context.WithTimeout(context.Background(), bulk.Global)
+	} else {
+		ctx, can = context.WithCancel(context.Background())
+	}
+
+	defer can()
+
+	var fco uint
+	var mut sync.Mutex
+	var res []error
+	{
+		res = make([]error, len(acts))
+	}
+
+	// exp returns the reason for which the given action must not be executed
+	// anymore, if any.
+	exp := func(las error) error {
+		mut.Lock()
+		defer mut.Unlock()
+
+		if ctx.Err() != nil {
+			return errors.Join(Cancel, Passed)
+		}
+
+		if bulk.Budget != 0 && fco >= bulk.Budget {
+			if las != nil {
+				return errors.Join(Cancel, las)
+			}
+
+			return tracer.Maskf(Cancel, "shared failure budget used up")
+		}
+
+		return nil
+	}
+
+	wra := func(act func() error) func() error {
+		var las error
+
+		return func() error {
+			var err error
+			{
+				mut.Lock()
+				err = las
+				mut.Unlock()
+			}
+
+			err = exp(err)
+			if err != nil {
+				return err
+			}
+
+			err = act()
+
+			{
+				mut.Lock()
+				if err != nil && bulk.Budget != 0 && !IsCancel(err) && !IsPending(err) && !IsRepeat(err) {
+					fco++
+				}
+				las = err
+				mut.Unlock()
+			}
+
+			return err
+		}
+	}
+
+	var wai sync.WaitGroup
+	var que chan int
+	{
+		que = make(chan int)
+	}
+
+	for i := uint(0); i < bulk.Concurrency; i++ {
+		wai.Add(1)
+		go func() {
+			defer wai.Done()
+
+			for j := range que {
+				act := wra(acts[j])
+				err := b.ExecuteContext(ctx, func(ctx context.Context) error { return act() })
+
+				mut.Lock()
+				res[j] = err
+				mut.Unlock()
+			}
+		}()
+	}
+
+	for j := range acts {
+		err := exp(nil)
+		if err != nil {
+			mut.Lock()
+			res[j] = err
+			mut.Unlock()
+			continue
+		}
+
+		que <- j
+	}
+
+	close(que)
+	wai.Wait()
+
+	return res
+}
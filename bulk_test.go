@@ -0,0 +1,186 @@
+package breakr
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/tracer"
+)
+
+func Test_Breakr_ExecuteAll_Concurrency(t *testing.T) {
+	var cou *counter
+	{
+		cou = &counter{}
+	}
+
+	var b *Breakr
+	{
+		b = New(Config{
+			Limiter: Limiter{
+				Budget: 3,
+			},
+		})
+	}
+
+	var act []func() error
+	for i := 0; i < 20; i++ {
+		act = append(act, func() error { cou.Inc(); defer cou.Dec(); time.Sleep(5 * time.Millisecond); return nil })
+	}
+
+	res := b.ExecuteAll(act, Bulk{})
+	for _, err := range res {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(res) != 20 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(20, len(res)))
+	}
+	if cou.Max() != 3 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint(3), cou.Max()))
+	}
+}
+
+func Test_Breakr_ExecuteAll_Budget(t *testing.T) {
+	var testError = &tracer.Error{
+		Kind: "testError",
+	}
+
+	var cou *counter
+	{
+		cou = &counter{}
+	}
+
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Budget: 5,
+				Cooler: -1,
+			},
+		})
+	}
+
+	var act []func() error
+	for i := 0; i < 3; i++ {
+		act = append(act, func() error { cou.Inc(); return testError })
+	}
+
+	res := b.ExecuteAll(act, Bulk{Budget: 4, Concurrency: 1})
+
+	// The shared budget of 4 is used up during the first action, which would
+	// otherwise have been executed 5 times. All actions have to be cancelled.
+	if cou.Cou() != 4 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint(4), cou.Cou()))
+	}
+
+	for _, err := range res {
+		if !IsCancel(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+	}
+
+	if !errors.Is(res[0], testError) {
+		t.Fatalf("expected error matcher to match")
+	}
+}
+
+func Test_Breakr_ExecuteAll_Global(t *testing.T) {
+	var b *Breakr
+	{
+		b = New(Config{})
+	}
+
+	var act []func() error
+	for i := 0; i < 10; i++ {
+		act = append(act, func() error { time.Sleep(30 * time.Millisecond); return nil })
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	res := b.ExecuteAll(act, Bulk{Concurrency: 1, Global: 50 * time.Millisecond})
+
+	if time.Since(sta) > 100*time.Millisecond {
+		t.Fatalf("expected global timeout to be honored")
+	}
+
+	if res[0] != nil {
+		t.Fatal(res[0])
+	}
+	if !IsPassed(res[9]) {
+		t.Fatalf("expected error matcher to match")
+	}
+}
+
+func Test_Breakr_ExecuteAll_Global_Retry(t *testing.T) {
+	var testError = &tracer.Error{
+		Kind: "testError",
+	}
+
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Cooler: time.Minute,
+			},
+		})
+	}
+
+	act := []func() error{
+		func() error { return testError },
+	}
+
+	res := b.ExecuteAll(act, Bulk{Global: 50 * time.Millisecond})
+	if !IsPassed(res[0]) {
+		t.Fatalf("expected error matcher to match")
+	}
+
+	// The execution loop of the action must have stopped cooling down once
+	// the global deadline expired, instead of keeping on in the background.
+	if b.Stats().Failure != 1 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint64(1), b.Stats().Failure))
+	}
+}
+
+func Test_Breakr_ExecuteAll_Pending(t *testing.T) {
+	var cou *counter
+	{
+		cou = &counter{}
+	}
+
+	var b *Breakr
+	{
+		b = New(Config{
+			Poller: Poller{
+				Interval: time.Millisecond,
+			},
+		})
+	}
+
+	act := []func() error{
+		func() error {
+			cou.Inc()
+			if cou.Cou() < 3 {
+				return tracer.Mask(Pending)
+			}
+
+			return nil
+		},
+	}
+
+	// Polling does not use up the shared failure budget.
+	res := b.ExecuteAll(act, Bulk{Budget: 1})
+	if res[0] != nil {
+		t.Fatal(res[0])
+	}
+
+	if cou.Cou() != 3 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint(3), cou.Cou()))
+	}
+}
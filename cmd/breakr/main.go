@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: breakr run [flags] -- command [args...]
+
+Run executes the given command under the policies of a breaker. Run the
+command "breakr run -h" for a list of all flags.
+`
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "run" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	os.Exit(run(os.Args[2:]))
+}
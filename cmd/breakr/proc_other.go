@@ -0,0 +1,24 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// group is a no-op on platforms without process groups.
+func group(cmd *exec.Cmd) {}
+
+// interrupted is always false on platforms without signals.
+func interrupted(cmd *exec.Cmd) bool { return false }
+
+// kill only signals the command itself on platforms without process groups.
+// Any signal other than SIGKILL is not supported and kills the command.
+func kill(cmd *exec.Cmd, sig syscall.Signal) error {
+	return cmd.Process.Signal(os.Kill)
+}
+
+// restore is a no-op on platforms without process groups.
+func restore(cmd *exec.Cmd) {}
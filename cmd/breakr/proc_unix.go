@@ -0,0 +1,43 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// group starts the command in its own process group, so that the command and
+// all of its children can be signalled at once. If breakr runs in the
+// foreground of the terminal attached to stdin, the process group of the
+// command is placed in the foreground instead, so that the command can read
+// from the terminal without being stopped by SIGTTIN. The command then
+// receives signals of the terminal like Ctrl-C directly, see interrupted.
+func group(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if foreground() {
+		cmd.SysProcAttr.Foreground = true
+		cmd.SysProcAttr.Ctty = int(os.Stdin.Fd())
+	}
+}
+
+// interrupted returns whether the command got killed by SIGINT.
+func interrupted(cmd *exec.Cmd) bool {
+	wai, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	return ok && wai.Signaled() && wai.Signal() == syscall.SIGINT
+}
+
+func kill(cmd *exec.Cmd, sig syscall.Signal) error {
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
+
+// restore places the process group of breakr back into the foreground of the
+// terminal, if the given command took it over, so that breakr receives
+// signals of the terminal again until the next attempt.
+func restore(cmd *exec.Cmd) {
+	if cmd.SysProcAttr.Foreground {
+		reclaim()
+	}
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/xh3b4sd/breakr"
+)
+
+const (
+	// exitClosed is the exit code used if the command got interrupted by a
+	// signal, following the shell convention of 128+SIGINT.
+	exitClosed = 130
+	// exitPassed is the exit code used if the command timed out, following the
+	// convention of timeout(1).
+	exitPassed = 124
+)
+
+type runFlags struct {
+	actionTimeout      time.Duration
+	cancelCodes        string
+	failureBudget      uint
+	failureCooler      time.Duration
+	globalTimeout      time.Duration
+	grace              time.Duration
+	repeatCodes        string
+	successBudget      uint
+	successCooler      time.Duration
+	successConsecutive bool
+	timeoutBudget      uint
+	timeoutCooler      time.Duration
+	timeoutFloor       time.Duration
+}
+
+func run(arg []string) int {
+	var fla runFlags
+	{
+		fla = runFlags{
+			actionTimeout: -1,
+			failureCooler: time.Second,
+			globalTimeout: -1,
+			grace:         10 * time.Second,
+			successCooler: -1,
+			timeoutCooler: -1,
+			timeoutFloor:  -1,
+		}
+	}
+
+	fs := flag.NewFlagSet("breakr run", flag.ContinueOnError)
+	fs.Var((*durationValue)(&fla.actionTimeout), "action-timeout", "Timeout of every attempt. The process group of the command is killed once it expires. Disabled with -1.")
+	fs.StringVar(&fla.cancelCodes, "cancel-exit-codes", "", "Comma separated exit codes of the command that stop retrying.")
+	fs.UintVar(&fla.failureBudget, "failure-budget", 3, "Maximum amount of failed attempts.")
+	fs.Var((*durationValue)(&fla.failureCooler), "failure-cooler", "Time to wait after every failed attempt. Disabled with -1.")
+	fs.Var((*durationValue)(&fla.globalTimeout), "global-timeout", "Timeout of all attempts. Disabled with -1.")
+	fs.Var((*durationValue)(&fla.grace), "grace", "Time the command is given to exit after forwarding SIGINT or SIGTERM, before its process group is killed.")
+	fs.StringVar(&fla.repeatCodes, "repeat-exit-codes", "", "Comma separated exit codes of the command that retry without using up the failure budget.")
+	fs.UintVar(&fla.successBudget, "success-budget", 1, "Required amount of successful attempts.")
+	fs.Var((*durationValue)(&fla.successCooler), "success-cooler", "Time to wait after every successful attempt. Disabled with -1.")
+	fs.BoolVar(&fla.successConsecutive, "success-consecutive", false, "Require successful attempts to happen in a row.")
+	fs.UintVar(&fla.timeoutBudget, "timeout-budget", 1, "Maximum amount of attempts that timed out.")
+	fs.Var((*durationValue)(&fla.timeoutCooler), "timeout-cooler", "Time to wait after every attempt that timed out. Disabled with -1.")
+	fs.Var((*durationValue)(&fla.timeoutFloor), "timeout-floor", "Minimum remaining global timeout required for another attempt. Disabled with -1.")
+
+	err := fs.Parse(arg)
+	if err != nil {
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	can, err := parseCodes(fla.cancelCodes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "breakr: invalid -cancel-exit-codes: %s\n", err)
+		return 2
+	}
+
+	rep, err := parseCodes(fla.repeatCodes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "breakr: invalid -repeat-exit-codes: %s\n", err)
+		return 2
+	}
+
+	clo := make(chan struct{})
+
+	var r *runner
+	{
+		r = &runner{
+			arg: fs.Args(),
+			can: can,
+			clo: clo,
+			rep: rep,
+		}
+	}
+
+	var b *breakr.Breakr
+	{
+		b = breakr.New(breakr.Config{
+			Failure: breakr.Failure{
+				Budget: fla.failureBudget,
+				Cooler: fla.failureCooler,
+			},
+			Success: breakr.Success{
+				Budget:      fla.successBudget,
+				Consecutive: fla.successConsecutive,
+				Cooler:      fla.successCooler,
+			},
+			Timeout: breakr.Timeout{
+				Action: fla.actionTimeout,
+				Budget: fla.timeoutBudget,
+				Closer: clo,
+				Cooler: fla.timeoutCooler,
+				Floor:  fla.timeoutFloor,
+				Global: fla.globalTimeout,
+			},
+		})
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	kil := make(chan struct{})
+
+	go func() {
+		s, ok := <-sig
+		if !ok {
+			return
+		}
+
+		r.Signal(s)
+		close(clo)
+
+		// A second signal cuts the grace period short, e.g. if the user
+		// pressed Ctrl-C twice.
+		_, ok = <-sig
+		if !ok {
+			return
+		}
+
+		close(kil)
+	}()
+
+	err = b.ExecuteContext(context.Background(), r.Attempt)
+
+	// Commands that got signalled are given the grace period to exit on their
+	// own, before their process group gets killed.
+	r.Wait(fla.grace, kil)
+
+	return exitCode(err, r.Code())
+}
+
+// exitCode maps the result of the breaker onto the exit code of breakr.
+func exitCode(err error, cod int) int {
+	if err == nil {
+		return 0
+	}
+
+	if breakr.IsClosed(err) {
+		return exitClosed
+	}
+
+	if breakr.IsPassed(err) {
+		return exitPassed
+	}
+
+	if cod > 0 {
+		return cod
+	}
+
+	return 1
+}
+
+func parseCodes(str string) (map[int]bool, error) {
+	cod := map[int]bool{}
+
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+
+		cod[i] = true
+	}
+
+	return cod, nil
+}
+
+type runner struct {
+	arg []string
+	can map[int]bool
+	clo <-chan struct{}
+	cmd *exec.Cmd
+	cod int
+	don chan struct{}
+	mut sync.Mutex
+	rep map[int]bool
+}
+
+// Attempt executes the command once. The process group of the command is
+// killed once ctx expires, which happens if the attempt timed out.
+func (r *runner) Attempt(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, r.arg[0], r.arg[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	group(cmd)
+
+	// Commands that got signalled are left to runner.Wait, which gives them
+	// the grace period to exit on their own.
+	cmd.Cancel = func() error {
+		select {
+		case <-r.clo:
+			return nil
+		default:
+		}
+
+		return kill(cmd, syscall.SIGKILL)
+	}
+
+	// Commands that cannot be started, e.g. because they do not exist, are
+	// not worth retrying.
+	err := cmd.Start()
+	if err != nil {
+		return errors.Join(breakr.Cancel, err)
+	}
+
+	don := make(chan struct{})
+	defer close(don)
+
+	{
+		r.mut.Lock()
+		r.cmd = cmd
+		r.don = don
+		r.mut.Unlock()
+	}
+
+	err = cmd.Wait()
+
+	// The command is forgotten right after it exited, so that signals are
+	// never sent to its process group anymore, which may belong to another
+	// process by now.
+	{
+		r.mut.Lock()
+		r.cmd = nil
+		r.mut.Unlock()
+	}
+
+	restore(cmd)
+
+	var exi *exec.ExitError
+	if errors.As(err, &exi) {
+		r.mut.Lock()
+		r.cod = exi.ExitCode()
+		r.mut.Unlock()
+
+		// Commands in the foreground of the terminal receive Ctrl-C instead
+		// of breakr, which is why they stop the execution loop like breakr
+		// receiving SIGINT would.
+		if interrupted(cmd) {
+			return errors.Join(breakr.Closed, err)
+		}
+
+		if r.can[exi.ExitCode()] {
+			return errors.Join(breakr.Cancel, err)
+		}
+
+		if r.rep[exi.ExitCode()] {
+			return errors.Join(breakr.Repeat, err)
+		}
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	{
+		r.mut.Lock()
+		r.cod = 0
+		r.mut.Unlock()
+	}
+
+	return nil
+}
+
+// Code returns the exit code of the last command that exited.
+func (r *runner) Code() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.cod
+}
+
+// Signal forwards the given signal to the process group of the command in
+// flight, if any.
+func (r *runner) Signal(sig os.Signal) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.cmd != nil {
+		_ = kill(r.cmd, sig.(syscall.Signal))
+	}
+}
+
+// Wait waits for the command in flight, if any, to exit. The process group of
+// the command is killed if it did not exit within the given grace period, or
+// once kil got closed.
+func (r *runner) Wait(gra time.Duration, kil <-chan struct{}) {
+	var cmd *exec.Cmd
+	var don chan struct{}
+	{
+		r.mut.Lock()
+		cmd = r.cmd
+		don = r.don
+		r.mut.Unlock()
+	}
+
+	if cmd == nil {
+		return
+	}
+
+	tim := time.NewTimer(gra)
+	defer tim.Stop()
+
+	select {
+	case <-don:
+	case <-tim.C:
+		r.Signal(syscall.SIGKILL)
+		<-don
+	case <-kil:
+		r.Signal(syscall.SIGKILL)
+		<-don
+	}
+}
+
+// durationValue is a flag.Value for durations, which additionally accepts -1
+// for disabling the respective setting, like the configuration of the
+// breaker does.
+type durationValue time.Duration
+
+func (d *durationValue) Set(str string) error {
+	if str == "-1" {
+		*d = -1
+		return nil
+	}
+
+	dur, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+
+	*d = durationValue(dur)
+
+	return nil
+}
+
+func (d *durationValue) String() string {
+	if d == nil {
+		return ""
+	}
+
+	if *d == -1 {
+		return "-1"
+	}
+
+	return time.Duration(*d).String()
+}
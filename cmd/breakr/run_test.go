@@ -0,0 +1,212 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Run(t *testing.T) {
+	testCases := []struct {
+		fla []string
+		scr string
+		cod int
+		cou int
+	}{
+		// Case 0 ensures that successful commands exit with 0.
+		{
+			fla: []string{"-failure-cooler", "-1"},
+			scr: "exit 0",
+			cod: 0,
+			cou: 1,
+		},
+		// Case 1 ensures that failing commands are retried until the failure
+		// budget is exhausted, exiting with the exit code of the last command.
+		{
+			fla: []string{"-failure-budget", "3", "-failure-cooler", "-1"},
+			scr: "exit 3",
+			cod: 3,
+			cou: 3,
+		},
+		// Case 2 ensures that cancel exit codes stop retrying immediately.
+		{
+			fla: []string{"-failure-budget", "3", "-failure-cooler", "-1", "-cancel-exit-codes", "2,3"},
+			scr: "exit 3",
+			cod: 3,
+			cou: 1,
+		},
+		// Case 3 ensures that repeat exit codes do not use up the failure budget.
+		{
+			fla: []string{"-failure-budget", "1", "-failure-cooler", "-1", "-repeat-exit-codes", "75"},
+			scr: `[ "$(wc -l < "$CNT")" -ge 3 ] || exit 75`,
+			cod: 0,
+			cou: 3,
+		},
+		// Case 4 ensures that commands timing out exit like timeout(1).
+		{
+			fla: []string{"-action-timeout", "100ms"},
+			scr: "sleep 5",
+			cod: exitPassed,
+			cou: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var cnt string
+			{
+				cnt = filepath.Join(t.TempDir(), "cnt")
+				t.Setenv("CNT", cnt)
+			}
+
+			var arg []string
+			{
+				arg = append(arg, tc.fla...)
+				arg = append(arg, "--", "sh", "-c", `echo x >> "$CNT"; `+tc.scr)
+			}
+
+			cod := run(arg)
+
+			if cod != tc.cod {
+				t.Fatalf("cod\n\n%s\n", cmp.Diff(tc.cod, cod))
+			}
+
+			var cou int
+			{
+				byt, err := os.ReadFile(cnt)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				cou = strings.Count(string(byt), "x")
+			}
+
+			if cou != tc.cou {
+				t.Fatalf("cou\n\n%s\n", cmp.Diff(tc.cou, cou))
+			}
+		})
+	}
+}
+
+func Test_Run_Timeout_Group(t *testing.T) {
+	var pid string
+	{
+		pid = filepath.Join(t.TempDir(), "pid")
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	// The command spawns a child process which has to be killed as well once
+	// the attempt timed out.
+	cod := run([]string{"-action-timeout", "100ms", "--", "sh", "-c", "sleep 5 & echo $! > " + pid + "; wait"})
+
+	if cod != exitPassed {
+		t.Fatalf("cod\n\n%s\n", cmp.Diff(exitPassed, cod))
+	}
+
+	if time.Since(sta) > 2*time.Second {
+		t.Fatalf("expected command to be killed after its action timeout")
+	}
+
+	var byt []byte
+	{
+		var err error
+		byt, err = os.ReadFile(pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Signal 0 only checks for the existence of the child process. The child
+	// may linger as zombie for a moment until it got reaped.
+	var exi bool
+	for j := 0; j < 50; j++ {
+		out, _ := os.ReadFile("/proc/" + strings.TrimSpace(string(byt)) + "/stat")
+		exi = len(out) != 0 && !strings.Contains(string(out), ") Z ")
+		if !exi {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if exi {
+		t.Fatalf("expected child process to be killed")
+	}
+}
+
+func Test_Run_Signal_Twice(t *testing.T) {
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+		time.Sleep(200 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	// The command ignores SIGTERM, which is why only the second signal makes
+	// breakr kill the command before the grace period ended.
+	cod := run([]string{"-grace", "5s", "--", "sh", "-c", "trap '' TERM; sleep 5"})
+
+	if cod != exitClosed {
+		t.Fatalf("cod\n\n%s\n", cmp.Diff(exitClosed, cod))
+	}
+
+	if time.Since(sta) > 2*time.Second {
+		t.Fatalf("expected command to be killed after the second signal")
+	}
+}
+
+func Test_Run_Start(t *testing.T) {
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	// Commands that cannot be started must not be retried, which would take
+	// at least one failure cooler.
+	cod := run([]string{"-failure-budget", "3", "-failure-cooler", "1s", "--", filepath.Join(t.TempDir(), "missing")})
+
+	if cod != 1 {
+		t.Fatalf("cod\n\n%s\n", cmp.Diff(1, cod))
+	}
+
+	if time.Since(sta) >= time.Second {
+		t.Fatalf("expected command not to be retried")
+	}
+}
+
+func Test_Runner_Attempt_Forget(t *testing.T) {
+	var r *runner
+	{
+		r = &runner{
+			arg: []string{"true"},
+		}
+	}
+
+	err := r.Attempt(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signals must not be sent to the process group of a command that exited
+	// already.
+	if r.cmd != nil {
+		t.Fatalf("expected command to be forgotten once it exited")
+	}
+}
@@ -0,0 +1,8 @@
+package main
+
+// foreground is always false on AIX, which does not support changing the
+// foreground process group of the terminal.
+func foreground() bool { return false }
+
+// reclaim is a no-op on AIX, see foreground.
+func reclaim() {}
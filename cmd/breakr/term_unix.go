@@ -0,0 +1,29 @@
+//go:build unix && !aix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// foreground returns whether breakr runs in the foreground process group of
+// the terminal attached to stdin.
+func foreground() bool {
+	pgr, err := unix.IoctlGetInt(int(os.Stdin.Fd()), unix.TIOCGPGRP)
+	return err == nil && pgr == syscall.Getpgrp()
+}
+
+// reclaim places the process group of breakr into the foreground of the
+// terminal attached to stdin. SIGTTOU is ignored meanwhile, since the kernel
+// would otherwise stop breakr for changing the foreground process group from
+// the background.
+func reclaim() {
+	signal.Ignore(syscall.SIGTTOU)
+	defer signal.Reset(syscall.SIGTTOU)
+
+	_ = unix.IoctlSetPointerInt(int(os.Stdin.Fd()), unix.TIOCSPGRP, syscall.Getpgrp())
+}
@@ -0,0 +1,88 @@
+package breakr
+
+import (
+	"context"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// Compose returns a breaker nesting the given policies in order. The first
+// policy is the outermost one and the last policy is the innermost one, which
+// executes the provided action directly. Policies are usually created using
+// Failure.Policy, Limiter.Policy, Success.Policy and Timeout.Policy. The
+// example below limits concurrent executions outside of the retry loop, while
+// every attempt gets its own timeout.
+//
+//	Compose(
+//	    Limiter{Budget: 5}.Policy(),
+//	    Failure{Budget: 3}.Policy(),
+//	    Timeout{Action: time.Second}.Policy(),
+//	)
+//
+// Inner policies are stopped once an outer policy gave up on them, e.g.
+// because the timeout policy wrapping them passed. Results of attempts that
+// timed out are discarded. New composes the same policies, see New.
+func Compose(pol ...Interface) Interface {
+	return &composed{pol: pol}
+}
+
+// policy is implemented by all standalone policies. Policies pass the context
+// of the execution on to the policies they wrap, so that inner policies stop
+// once the context is done.
+type policy interface {
+	Interface
+	apply(ctx context.Context, act func(ctx context.Context) error) error
+}
+
+type composed struct {
+	pol []Interface
+}
+
+func (c *composed) Execute(act func() error) error {
+	err := c.Wrapper(act)()
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *composed) Wrapper(act func() error) func() error {
+	return wrapper(c.pol, act)
+}
+
+func (c *composed) apply(ctx context.Context, act func(ctx context.Context) error) error {
+	return compose(ctx, c.pol, act)
+}
+
+// compose executes act through the given policies, the first policy being the
+// outermost one. Policies not implementing policy are executed using their
+// Wrapper, which does not pass the context of the execution on.
+func compose(ctx context.Context, pol []Interface, act func(ctx context.Context) error) error {
+	if len(pol) == 0 {
+		return recovered(ctx, act)
+	}
+
+	nxt := func(ctx context.Context) error {
+		return compose(ctx, pol[1:], act)
+	}
+
+	p, ok := pol[0].(policy)
+	if ok {
+		return p.apply(ctx, nxt)
+	}
+
+	return pol[0].Wrapper(func() error { return nxt(ctx) })()
+}
+
+// wrapper returns the Wrapper of the given policies.
+func wrapper(pol []Interface, act func() error) func() error {
+	return func() error {
+		err := compose(context.Background(), pol, func(context.Context) error { return act() })
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+package breakr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Compose_Interface(t *testing.T) {
+	var _ Interface = Compose()
+	var _ Interface = Failure{}.Policy()
+	var _ Interface = Limiter{}.Policy()
+	var _ Interface = Success{}.Policy()
+	var _ Interface = Timeout{}.Policy()
+}
+
+func Test_Compose_Order(t *testing.T) {
+	var cou *counter
+	{
+		cou = &counter{}
+	}
+
+	testCases := []struct {
+		pol []Interface
+		act func() error
+		cou int
+		mat func(err error) bool
+	}{
+		// Case 0 ensures that a limiter composed outside of a retry policy
+		// admits the call once, regardless of the attempts made.
+		{
+			pol: []Interface{
+				Limiter{Budget: 1, Cooler: time.Minute}.Policy(),
+				Failure{Budget: 3, Cooler: -1}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				if cou.Cou() < 3 {
+					return fmt.Errorf("test error")
+				}
+				return nil
+			},
+			cou: 3,
+			mat: func(err error) bool { return err == nil },
+		},
+		// Case 1 ensures that a limiter composed inside of a retry policy
+		// admits every attempt, which fills the limiter on the second attempt.
+		{
+			pol: []Interface{
+				Failure{Budget: 3, Cooler: -1}.Policy(),
+				Limiter{Budget: 1, Cooler: time.Minute}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				return fmt.Errorf("test error")
+			},
+			cou: 1,
+			mat: IsFilled,
+		},
+		// Case 2 ensures that a timeout composed inside of a retry policy
+		// bounds every attempt.
+		{
+			pol: []Interface{
+				Failure{Budget: 3, Cooler: -1}.Policy(),
+				Timeout{Action: 10 * time.Millisecond}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			},
+			cou: 3,
+			mat: IsPassed,
+		},
+		// Case 3 ensures that a success policy composed inside of a retry
+		// policy requires successful executions to happen in a row.
+		{
+			pol: []Interface{
+				Failure{Budget: 3, Cooler: -1}.Policy(),
+				Success{Budget: 2}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				if cou.Cou() == 2 {
+					return fmt.Errorf("test error")
+				}
+				return nil
+			},
+			cou: 4,
+			mat: func(err error) bool { return err == nil },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			// Note that this counter has to be reset for each test in order to
+			// lead to accurate results.
+			cou.Res()
+
+			err := Compose(tc.pol...).Execute(tc.act)
+			if !tc.mat(err) {
+				t.Fatalf("expected error matcher to match")
+			}
+
+			if cou.Cou() != uint(tc.cou) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(uint(tc.cou), cou.Cou()))
+			}
+		})
+	}
+}
+
+func Test_Compose_Failure(t *testing.T) {
+	var cou *counter
+	{
+		cou = &counter{}
+	}
+
+	testCases := []struct {
+		pol []Interface
+		act func() error
+		cou int
+		mat func(err error) bool
+	}{
+		// Case 0 ensures that the retry policy recovers panics, if configured,
+		// even if they happen inside of an inner timeout policy.
+		{
+			pol: []Interface{
+				Failure{Budget: 3, Cooler: -1, Recover: true}.Policy(),
+				Timeout{Action: time.Second}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				panic("test panic")
+			},
+			cou: 3,
+			mat: IsPanic,
+		},
+		// Case 1 ensures that the retry policy does not retry actions that can
+		// never be admitted.
+		{
+			pol: []Interface{
+				Failure{Budget: 3, Cooler: -1}.Policy(),
+				Limiter{Budget: 1}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				return Oversized
+			},
+			cou: 1,
+			mat: IsOversized,
+		},
+		// Case 2 ensures that an outer timeout policy interrupts the cooldown
+		// of the retry policy, which does not execute the action again.
+		{
+			pol: []Interface{
+				Timeout{Action: 50 * time.Millisecond}.Policy(),
+				Failure{Budget: 3, Cooler: 100 * time.Millisecond}.Policy(),
+			},
+			act: func() error {
+				cou.Inc()
+				return fmt.Errorf("test error")
+			},
+			cou: 1,
+			mat: IsPassed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			// Note that this counter has to be reset for each test in order to
+			// lead to accurate results.
+			cou.Res()
+
+			err := Compose(tc.pol...).Execute(tc.act)
+			if !tc.mat(err) {
+				t.Fatalf("expected error matcher to match")
+			}
+
+			// Policies that got interrupted must not execute the action again
+			// in the background.
+			time.Sleep(200 * time.Millisecond)
+
+			if cou.Cou() != uint(tc.cou) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(uint(tc.cou), cou.Cou()))
+			}
+		})
+	}
+}
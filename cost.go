@@ -0,0 +1,43 @@
+package breakr
+
+import (
+	"context"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// WithCost returns a copy of ctx carrying the given cost, which the limiter
+// reserves from Limiter.Budget for actions executed using ctx, instead of a
+// cost of 1. See Breakr.ExecuteCost.
+func WithCost(ctx context.Context, cos uint) context.Context {
+	return context.WithValue(ctx, costKey, cos)
+}
+
+// cost returns the cost carried by ctx, defaulting to 1.
+func cost(ctx context.Context) uint {
+	if ctx == nil {
+		return 1
+	}
+
+	cos, ok := ctx.Value(costKey).(uint)
+	if !ok || cos == 0 {
+		return 1
+	}
+
+	return cos
+}
+
+// ExecuteCost executes act like Breakr.Execute, while every attempt reserves
+// the given cost from Limiter.Budget, like a weighted semaphore. Expensive
+// actions therefore take away more of the limiter's budget, both from the
+// actions executing at the same time and from the time window defined by
+// Limiter.Cooler. Oversized is returned without executing act if the cost
+// exceeds Limiter.Budget. A cost of 0 is treated like a cost of 1.
+func (b *Breakr) ExecuteCost(cos uint, act func() error) error {
+	err := b.ExecuteContext(WithCost(context.Background(), cos), func(context.Context) error { return act() })
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Limiter_Cost(t *testing.T) {
+	testCases := []struct {
+		coo time.Duration
+		hol uint
+		cos uint
+		mat func(err error) bool
+	}{
+		// Case 0 ensures that actions fitting into the remaining budget are
+		// admitted.
+		{
+			coo: -1,
+			hol: 6,
+			cos: 4,
+			mat: func(err error) bool { return err == nil },
+		},
+		// Case 1 ensures that actions exceeding the remaining budget are
+		// rejected.
+		{
+			coo: -1,
+			hol: 6,
+			cos: 5,
+			mat: IsFilled,
+		},
+		// Case 2 ensures that actions exceeding the budget are rejected for
+		// good.
+		{
+			coo: -1,
+			hol: 0,
+			cos: 11,
+			mat: IsOversized,
+		},
+		// Case 3 ensures that the time window accounts for the cost of
+		// actions.
+		{
+			coo: time.Second,
+			hol: 7,
+			cos: 4,
+			mat: func(err error) bool {
+				var fil *FilledError
+				return errors.As(err, &fil) && fil.Delay > 0 && fil.Delay <= time.Second
+			},
+		},
+		// Case 4 ensures that actions fitting into the remaining time window are
+		// admitted.
+		{
+			coo: time.Second,
+			hol: 7,
+			cos: 3,
+			mat: func(err error) bool { return err == nil },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var l *limiter
+			{
+				c := Limiter{
+					Budget: 10,
+					Cooler: tc.coo,
+				}
+
+				l = c.New()
+			}
+
+			blo := make(chan struct{})
+			if tc.hol != 0 {
+				run := make(chan struct{})
+				go func(hol uint) {
+					_ = l.ExecuteCost(hol, func() error {
+						close(run)
+						<-blo
+						return nil
+					})
+				}(tc.hol)
+				<-run
+			}
+
+			// Actions within a time window do not need to execute at the same
+			// time in order to use up its budget.
+			if tc.coo != -1 {
+				close(blo)
+			}
+
+			err := l.ExecuteCost(tc.cos, func() error { return nil })
+			if !tc.mat(err) {
+				t.Fatalf("expected error to match, got %#v", err)
+			}
+
+			if tc.coo == -1 {
+				close(blo)
+			}
+		})
+	}
+}
+
+func Test_Limiter_Cost_Queue(t *testing.T) {
+	var l *limiter
+	{
+		c := Limiter{
+			Budget: 4,
+			Cooler: -1,
+			Queue:  10,
+		}
+
+		l = c.New()
+	}
+
+	blo := make(chan struct{})
+	run := make(chan struct{})
+	go func() {
+		_ = l.ExecuteCost(3, func() error {
+			close(run)
+			<-blo
+			return nil
+		})
+	}()
+	<-run
+
+	var mut sync.Mutex
+	var ord []uint
+
+	var wg sync.WaitGroup
+	for j, c := range []uint{4, 1} {
+		cos := c
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_ = l.ExecuteContext(WithCost(context.Background(), cos), func() error {
+				mut.Lock()
+				ord = append(ord, cos)
+				mut.Unlock()
+				return nil
+			})
+		}()
+
+		// The cheap action has to wait behind the expensive one, although it
+		// would fit into the remaining budget.
+		cou := uint(j + 1)
+		waitFor(t, func() bool {
+			l.mut.Lock()
+			defer l.mut.Unlock()
+			return l.waiting() == cou
+		})
+	}
+
+	close(blo)
+	wg.Wait()
+
+	if !cmp.Equal([]uint{4, 1}, ord) {
+		t.Fatalf("ord\n\n%s\n", cmp.Diff([]uint{4, 1}, ord))
+	}
+	if l.run != 0 {
+		t.Fatalf("expected all budget to be released, got %d", l.run)
+	}
+}
+
+func Test_Breakr_ExecuteCost(t *testing.T) {
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Budget: 3,
+				Cooler: -1,
+			},
+			Limiter: Limiter{
+				Budget: 5,
+			},
+		})
+	}
+
+	var cou counter
+
+	err := b.ExecuteCost(6, func() error {
+		cou.Inc()
+		return nil
+	})
+	if !IsOversized(err) {
+		t.Fatalf("expected Oversized, got %#v", err)
+	}
+	if cou.Cou() != 0 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint(0), cou.Cou()))
+	}
+
+	err = b.ExecuteCost(5, func() error {
+		cou.Inc()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cou.Cou() != 1 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(uint(1), cou.Cou()))
+	}
+}
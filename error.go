@@ -2,6 +2,7 @@ package breakr
 
 import (
 	"errors"
+	"time"
 
 	"github.com/xh3b4sd/tracer"
 )
@@ -33,6 +34,42 @@ func IsFilled(err error) bool {
 	return errors.Is(err, Filled)
 }
 
+// FilledError is the error returned by the limiter and Quota if they refuse
+// to execute an action. FilledError matches Filled and carries the amount of
+// time after which actions are expected to be admitted again.
+type FilledError struct {
+	// Anno is the human readable annotation describing the reason of the
+	// rejection.
+	Anno string
+	// Delay is the remaining time until the limiter expects to admit actions
+	// again. Delay is 0 if the limiter cannot tell, e.g. because all actions
+	// allowed to execute concurrently are still running.
+	Delay time.Duration
+	// Reset is the time at which actions are expected to be admitted again.
+	// Reset is zero if the time cannot be told.
+	Reset time.Time
+	// Window is the name of the rate window of the limiter that rejected the
+	// action, if any. See Window.Name.
+	Window string
+}
+
+func (e *FilledError) Error() string {
+	return Filled.Error() + ": " + e.Anno
+}
+
+func (e *FilledError) Is(err error) bool {
+	return err == Filled
+}
+
+var Oversized = &tracer.Error{
+	Kind: "oversized",
+	Desc: "Oversized is the error returned by the limiter if the cost of an action exceeds the budget of the limiter. Such actions can never be admitted, which is why budget implementations stop any further executions.",
+}
+
+func IsOversized(err error) bool {
+	return errors.Is(err, Oversized)
+}
+
 var Passed = &tracer.Error{
 	Kind: "passed",
 	Desc: "Passed is the error returned by budget implementations if the configured timeout expired. Timeouts may apply to individual executions of the configured action or globally for a speficic execution of Breakr.Execute.",
@@ -42,6 +79,15 @@ func IsPassed(err error) bool {
 	return errors.Is(err, Passed)
 }
 
+var Pending = &tracer.Error{
+	Kind: "pending",
+	Desc: "Pending is the error returned by actions in order to signal that the condition they are waiting for does not hold yet. The execution loop is repeated after the configured poll interval without taking away from any failure or success budget.",
+}
+
+func IsPending(err error) bool {
+	return errors.Is(err, Pending)
+}
+
 var Repeat = &tracer.Error{
 	Kind: "repeat",
 	Desc: "Repeat is the error returned by actions in order to repeat the execution loop early without taking away from any failure or success budget.",
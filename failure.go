@@ -20,4 +20,53 @@ type Failure struct {
 	//     * return error
 	//
 	Cooler time.Duration
+	// Classify is the optional function deciding how any error returned by an
+	// attempt is handled. The returned error replaces the original error
+	// within the execution loop, unless it is nil. Wrapping an error in Cancel
+	// stops the execution loop, while wrapping it in Repeat retries without
+	// taking away from the failure budget. The example below prevents panics
+	// from being retried.
+	//
+	//     func(err error) error {
+	//         if breakr.IsPanic(err) {
+	//             return errors.Join(breakr.Cancel, err)
+	//         }
+	//
+	//         return err
+	//     }
+	//
+	Classify func(err error) error
+	// Recover defines whether panics of the provided action are recovered.
+	// Recovered panics are returned as PanicError and handled like any other
+	// error, subject to Classify. Defaults to false.
+	Recover bool
+}
+
+// Policy returns the retry policy of the failure budget, executing the
+// provided action until it succeeds or the failure budget is used up. Actions
+// returning Repeat or Pending are executed again without taking away from the
+// failure budget. Actions returning Cancel, Closed, Filled or Oversized are
+// not executed again. Passed returned by an inner timeout policy uses up the
+// failure budget like any other error. Cooldowns are interrupted once an
+// outer policy gave up on the retry policy.
+func (f Failure) Policy() Interface {
+	if f.Budget == 0 {
+		f.Budget = 3
+	}
+	if f.Cooler == 0 {
+		f.Cooler = 1 * time.Second
+	}
+
+	r := &retry{
+		fai: f,
+		pol: Poller{Interval: -1},
+		suc: Success{Budget: 1, Cooler: -1},
+		tim: Timeout{Action: -1, Cooler: -1, Floor: -1},
+	}
+
+	if f.Recover {
+		r.rec = &recoverer{}
+	}
+
+	return r
 }
@@ -0,0 +1,121 @@
+package breakr
+
+import (
+	"sync"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// ExecuteWithFallback executes act like Breakr.Execute. Once the breaker gives
+// up on act, fal is executed with the error that caused the breaker to give
+// up. That is the case if the failure budget got exhausted, if the timeout
+// passed, if the limiter is filled or if the configured signal channel got
+// closed. The error returned by fal is returned as is.
+func (b *Breakr) ExecuteWithFallback(act func() error, fal func(err error) error) error {
+	err := b.Wrapper(act)()
+	if err != nil {
+		{
+			b.sta.fal.Add(1)
+		}
+
+		if b.obs.Fallback != nil {
+			b.obs.Fallback(err)
+		}
+
+		err = fal(err)
+		if err != nil {
+			return tracer.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// ExecuteWithFallback executes act exactly once and executes fal with the
+// error returned by act, if any.
+func (s *Single) ExecuteWithFallback(act func() error, fal func(err error) error) error {
+	err := s.Wrapper(act)()
+	if err != nil {
+		err = fal(err)
+		if err != nil {
+			return tracer.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// ExecuteWithFallbackOf is the typed variant of Breakr.ExecuteWithFallback. The
+// result of the last successful execution of act is returned, unless the
+// breaker gave up on act, in which case the result of fal is returned.
+func ExecuteWithFallbackOf[T any](b Interface, act func() (T, error), fal func(err error) (T, error)) (T, error) {
+	var mut sync.Mutex
+	var res T
+	var fbk bool
+	var fbv T
+
+	// Note that act may still be running in the background after the breaker
+	// moved on due to an action timeout. The result is therefore guarded so
+	// that late executions do not race with the caller reading the result.
+	wra := func() error {
+		val, err := act()
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		{
+			mut.Lock()
+			res = val
+			mut.Unlock()
+		}
+
+		return nil
+	}
+
+	fab := func(cau error) error {
+		val, err := fal(cau)
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		{
+			mut.Lock()
+			fbk = true
+			fbv = val
+			mut.Unlock()
+		}
+
+		return nil
+	}
+
+	var err error
+	{
+		f, ok := b.(fallbacker)
+		if ok {
+			err = f.ExecuteWithFallback(wra, fab)
+		} else {
+			err = b.Execute(wra)
+			if err != nil {
+				err = fab(err)
+			}
+		}
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if err != nil {
+		var zer T
+		return zer, tracer.Mask(err)
+	}
+
+	if fbk {
+		return fbv, nil
+	}
+
+	return res, nil
+}
+
+type fallbacker interface {
+	ExecuteWithFallback(act func() error, fal func(err error) error) error
+}
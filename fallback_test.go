@@ -0,0 +1,142 @@
+package breakr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/tracer"
+)
+
+func Test_Breakr_Fallback(t *testing.T) {
+	var testError = &tracer.Error{
+		Kind: "testError",
+	}
+
+	testCases := []struct {
+		act func() error
+		mat func(err error) bool
+		fal uint64
+	}{
+		// Case 0 ensures that the fallback is not executed if the action
+		// succeeds.
+		{
+			act: func() error {
+				return nil
+			},
+			mat: nil,
+			fal: 0,
+		},
+		// Case 1 ensures that the fallback receives the action error once the
+		// failure budget is used up.
+		{
+			act: func() error {
+				return testError
+			},
+			mat: func(err error) bool {
+				return errors.Is(err, testError)
+			},
+			fal: 1,
+		},
+		// Case 2 ensures that the fallback receives Cancel.
+		{
+			act: func() error {
+				return tracer.Mask(Cancel)
+			},
+			mat: IsCancel,
+			fal: 1,
+		},
+		// Case 3 ensures that the fallback receives Passed.
+		{
+			act: func() error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			},
+			mat: IsPassed,
+			fal: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var cau error
+			var hoo error
+
+			var b *Breakr
+			{
+				b = New(Config{
+					Failure: Failure{
+						Budget: 2,
+						Cooler: -1,
+					},
+					Observer: Observer{
+						Fallback: func(err error) { hoo = err },
+					},
+					Timeout: Timeout{
+						Action: 10 * time.Millisecond,
+					},
+				})
+			}
+
+			err := b.ExecuteWithFallback(tc.act, func(err error) error { cau = err; return nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.mat == nil && cau != nil {
+				t.Fatalf("expected fallback not to be executed")
+			}
+			if tc.mat != nil && !tc.mat(cau) {
+				t.Fatalf("expected error matcher to match")
+			}
+			if hoo != cau {
+				t.Fatalf("expected observer to receive fallback cause")
+			}
+
+			if b.Stats().Fallback != tc.fal {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.fal, b.Stats().Fallback))
+			}
+		})
+	}
+}
+
+func Test_Breakr_Fallback_Of(t *testing.T) {
+	var b Interface
+	{
+		b = New(Config{
+			Failure: Failure{
+				Budget: 2,
+				Cooler: -1,
+			},
+		})
+	}
+
+	{
+		val, err := ExecuteWithFallbackOf(b, func() (string, error) { return "act", nil }, func(err error) (string, error) { return "fal", nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != "act" {
+			t.Fatalf("\n\n%s\n", cmp.Diff("act", val))
+		}
+	}
+
+	{
+		val, err := ExecuteWithFallbackOf(b, func() (string, error) { return "", fmt.Errorf("test error") }, func(err error) (string, error) { return "fal", nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if val != "fal" {
+			t.Fatalf("\n\n%s\n", cmp.Diff("fal", val))
+		}
+	}
+
+	{
+		_, err := ExecuteWithFallbackOf(Fake(), func() (int, error) { return 0, Cancel }, func(err error) (int, error) { return 0, tracer.Mask(err) })
+		if !IsCancel(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package breakr
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// FileStore is a StateStore persisting every key as JSON file in a single
+// directory. Updates are serialized using file locks, so that processes
+// sharing the same directory on the same host can share the state of their
+// breakers. Files are written atomically by renaming temporary files.
+type FileStore struct {
+	dir string
+	mut sync.Mutex
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{
+		dir: dir,
+	}
+}
+
+func (f *FileStore) Update(key string, fn func(sta *State) error) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	err := os.MkdirAll(f.dir, 0700)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	var pat string
+	{
+		pat = filepath.Join(f.dir, url.PathEscape(key))
+	}
+
+	loc, err := os.OpenFile(pat+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+	defer loc.Close()
+
+	err = lock(loc)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+	defer unlock(loc) //nolint:errcheck
+
+	var sta State
+	{
+		byt, err := os.ReadFile(pat + ".json")
+		if os.IsNotExist(err) {
+			// fall through
+		} else if err != nil {
+			return tracer.Mask(err)
+		} else {
+			err = json.Unmarshal(byt, &sta)
+			if err != nil {
+				return tracer.Mask(err)
+			}
+		}
+	}
+
+	err = fn(&sta)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	byt, err := json.Marshal(sta)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	err = write(pat+".json", byt)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+// write replaces the file at the given path atomically, so that concurrent
+// readers never observe partially written files.
+func write(pat string, byt []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(pat), filepath.Base(pat)+".*.tmp")
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	{
+		_, err = tmp.Write(byt)
+		if err != nil {
+			_ = tmp.Close()
+			return tracer.Mask(err)
+		}
+
+		err = tmp.Sync()
+		if err != nil {
+			_ = tmp.Close()
+			return tracer.Mask(err)
+		}
+
+		err = tmp.Close()
+		if err != nil {
+			return tracer.Mask(err)
+		}
+	}
+
+	err = os.Rename(tmp.Name(), pat)
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
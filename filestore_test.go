@@ -0,0 +1,120 @@
+package breakr
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_FileStore_Update(t *testing.T) {
+	var dir string
+	{
+		dir = t.TempDir()
+	}
+
+	var wai sync.WaitGroup
+
+	// Every goroutine uses its own store instance, which is equivalent to
+	// separate processes sharing the same directory.
+	for i := 0; i < 10; i++ {
+		wai.Add(1)
+		go func() {
+			defer wai.Done()
+
+			sto := NewFileStore(dir)
+
+			for j := 0; j < 5; j++ {
+				err := sto.Update("key", func(sta *State) error {
+					sta.Limiter = append(sta.Limiter, time.Now())
+					return nil
+				})
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	wai.Wait()
+
+	var sto *FileStore
+	{
+		sto = NewFileStore(dir)
+	}
+
+	{
+		err := sto.Update("key", func(sta *State) error {
+			if len(sta.Limiter) != 50 {
+				t.Fatalf("\n\n%s\n", cmp.Diff(50, len(sta.Limiter)))
+			}
+
+			sta.Limiter = nil
+
+			return errors.New("test error")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	// The state must not be stored if the update failed.
+	{
+		err := sto.Update("key", func(sta *State) error {
+			if len(sta.Limiter) != 50 {
+				t.Fatalf("\n\n%s\n", cmp.Diff(50, len(sta.Limiter)))
+			}
+
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_FileStore_Limiter(t *testing.T) {
+	var dir string
+	{
+		dir = t.TempDir()
+	}
+
+	newBreakr := func() *Breakr {
+		return New(Config{
+			Limiter: Limiter{
+				Budget: 2,
+				Cooler: time.Minute,
+				Store:  NewFileStore(dir),
+			},
+		})
+	}
+
+	{
+		b := newBreakr()
+
+		for i := 0; i < 2; i++ {
+			err := b.Execute(func() error { return nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// A new breaker, e.g. in a restarted process, must respect the admissions
+	// of the previous breaker.
+	{
+		b := newBreakr()
+
+		err := b.Execute(func() error { return nil })
+		if !IsFilled(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+
+		var fil *FilledError
+		if !errors.As(err, &fil) || fil.Delay <= 50*time.Second {
+			t.Fatalf("expected remaining throttle time")
+		}
+	}
+}
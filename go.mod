@@ -6,3 +6,5 @@ require (
 	github.com/google/go-cmp v0.6.0
 	github.com/xh3b4sd/tracer v0.11.1
 )
+
+require golang.org/x/sys v0.11.0
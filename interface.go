@@ -1,6 +1,15 @@
 package breakr
 
+import "context"
+
 type Interface interface {
 	Execute(act func() error) error
 	Wrapper(act func() error) func() error
 }
+
+// ContextExecutor is implemented by breakers handing every attempt its own
+// context, like Breakr. Integrations use it to bind their attempts to the
+// deadline of the attempt, if the configured Interface supports it.
+type ContextExecutor interface {
+	ExecuteContext(ctx context.Context, act func(ctx context.Context) error) error
+}
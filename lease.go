@@ -0,0 +1,174 @@
+package breakr
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// LeaseReport describes an action holding on to an expired lease of a
+// limiter, see Limiter.Lease.
+type LeaseReport struct {
+	// Cost is the share of the limiter's budget the action got admitted with,
+	// which got reclaimed already.
+	Cost uint
+	// Goroutine is the ID of the goroutine executing the action.
+	Goroutine uint64
+	// Stack is the current stack trace of the goroutine executing the
+	// action, showing where the action hangs. Stack is empty if the stack
+	// trace could not be captured.
+	Stack []byte
+	// Start is the time at which the action got admitted.
+	Start time.Time
+}
+
+// lease is the share of the limiter's budget held by an admitted action.
+type lease struct {
+	cos uint
+	exp bool
+	gid uint64
+	sta time.Time
+	tim *time.Timer
+}
+
+// hold registers the lease of an action admitted with the given cost, which
+// is executed on the calling goroutine. The cost is reclaimed once the lease
+// expired, unless the action released it using free before.
+func (l *limiter) hold(cos uint) *lease {
+	lea := &lease{
+		cos: cos,
+		gid: goroutine(),
+		sta: time.Now(),
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.lel == nil {
+		l.lel = map[*lease]struct{}{}
+	}
+
+	l.lel[lea] = struct{}{}
+
+	lea.tim = time.AfterFunc(l.lea, func() {
+		l.mut.Lock()
+		defer l.mut.Unlock()
+
+		if _, ok := l.lel[lea]; !ok || lea.exp {
+			return
+		}
+
+		lea.exp = true
+		l.handover(lea.cos)
+	})
+
+	return lea
+}
+
+// free releases the given lease once its action finished. The cost of the
+// lease is only given back if it was not reclaimed already.
+func (l *limiter) free(lea *lease) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	lea.tim.Stop()
+
+	delete(l.lel, lea)
+
+	if !lea.exp {
+		l.handover(lea.cos)
+	}
+}
+
+// Expired returns a report of all actions that are still executing, although
+// their lease expired already, together with their current stack traces.
+func (l *limiter) Expired() []LeaseReport {
+	var rep []LeaseReport
+	{
+		l.mut.Lock()
+		for lea := range l.lel {
+			if !lea.exp {
+				continue
+			}
+
+			rep = append(rep, LeaseReport{
+				Cost:      lea.cos,
+				Goroutine: lea.gid,
+				Start:     lea.sta,
+			})
+		}
+		l.mut.Unlock()
+	}
+
+	if len(rep) == 0 {
+		return nil
+	}
+
+	sta := stacks()
+	for i := range rep {
+		rep[i].Stack = sta[rep[i].Goroutine]
+	}
+
+	return rep
+}
+
+// Expired returns a report of all actions executed by the breaker instance
+// that are still executing, although their lease expired already. See
+// Limiter.Lease.
+func (b *Breakr) Expired() []LeaseReport {
+	return b.lim.Expired()
+}
+
+// goroutine returns the ID of the calling goroutine, as printed in the header
+// of its stack trace, e.g. "goroutine 18 [running]:".
+func goroutine() uint64 {
+	var buf [64]byte
+
+	byt := buf[:runtime.Stack(buf[:], false)]
+	byt = bytes.TrimPrefix(byt, []byte("goroutine "))
+
+	if i := bytes.IndexByte(byt, ' '); i != -1 {
+		byt = byt[:i]
+	}
+
+	gid, err := strconv.ParseUint(string(byt), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return gid
+}
+
+// stacks returns the stack traces of all goroutines by their ID.
+func stacks() map[uint64][]byte {
+	var byt []byte
+	for siz := 1 << 16; ; siz *= 2 {
+		byt = make([]byte, siz)
+
+		n := runtime.Stack(byt, true)
+		if n < siz {
+			byt = byt[:n]
+			break
+		}
+	}
+
+	sta := map[uint64][]byte{}
+	for _, blo := range bytes.Split(byt, []byte("\n\n")) {
+		hea := bytes.TrimPrefix(blo, []byte("goroutine "))
+
+		i := bytes.IndexByte(hea, ' ')
+		if i == -1 {
+			continue
+		}
+
+		gid, err := strconv.ParseUint(string(hea[:i]), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		sta[gid] = blo
+	}
+
+	return sta
+}
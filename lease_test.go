@@ -0,0 +1,112 @@
+package breakr
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Limiter_Lease(t *testing.T) {
+	testCases := []struct {
+		que uint
+	}{
+		// Case 0 ensures that new actions are admitted once the lease of a
+		// hanging action expired.
+		{
+			que: 0,
+		},
+		// Case 1 ensures that waiting actions are admitted once the lease of
+		// a hanging action expired.
+		{
+			que: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var l *limiter
+			{
+				c := Limiter{
+					Budget: 1,
+					Cooler: -1,
+					Lease:  50 * time.Millisecond,
+					Queue:  tc.que,
+				}
+
+				l = c.New()
+			}
+
+			blo := make(chan struct{})
+			run := make(chan struct{})
+			don := make(chan struct{})
+			go func() {
+				defer close(don)
+				_ = l.Execute(func() error {
+					close(run)
+					hangingAction(blo)
+					return nil
+				})
+			}()
+			<-run
+
+			if tc.que == 0 {
+				err := l.Execute(func() error { return nil })
+				if !IsFilled(err) {
+					t.Fatalf("expected Filled, got %#v", err)
+				}
+
+				waitFor(t, func() bool {
+					return l.Execute(func() error { return nil }) == nil
+				})
+			} else {
+				var sta time.Time
+				{
+					sta = time.Now()
+				}
+
+				err := l.Execute(func() error { return nil })
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if time.Since(sta) < 40*time.Millisecond {
+					t.Fatalf("expected action to wait for the lease to expire")
+				}
+			}
+
+			var rep []LeaseReport
+			{
+				rep = l.Expired()
+			}
+
+			if len(rep) != 1 {
+				t.Fatalf("rep\n\n%s\n", cmp.Diff(1, len(rep)))
+			}
+			if !bytes.Contains(rep[0].Stack, []byte("hangingAction")) {
+				t.Fatalf("expected stack of hanging action, got\n\n%s\n", rep[0].Stack)
+			}
+			if rep[0].Cost != 1 || rep[0].Goroutine == 0 {
+				t.Fatalf("unexpected report %#v", rep[0])
+			}
+
+			close(blo)
+			<-don
+
+			// The hanging action finishing must not give back its share of the
+			// budget a second time.
+			if len(l.Expired()) != 0 {
+				t.Fatalf("expected no expired leases")
+			}
+			if l.run != 0 {
+				t.Fatalf("run\n\n%s\n", cmp.Diff(uint(0), l.run))
+			}
+		})
+	}
+}
+
+func hangingAction(blo chan struct{}) {
+	<-blo
+}
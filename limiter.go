@@ -1,6 +1,10 @@
 package breakr
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,88 +24,561 @@ type Limiter struct {
 	// actions within 500ms, given the default configuration of Budget at 3.
 	// Defaults to -1. Disabled with -1.
 	Cooler time.Duration
+	// Key is the name under which the limiter state is persisted using Store.
+	// Limiters sharing the same Store and Key share their time window.
+	// Defaults to "limiter".
+	Key string
+	// Lease is the optional maximum time an admitted action holds on to its
+	// share of Budget. Once the lease of an action expired, its share is
+	// reclaimed and handed over to other actions, while the action itself
+	// keeps executing. Actions holding expired leases are reported by
+	// Breakr.Expired, so that hanging actions can be found. Lease does not
+	// affect the time window defined by Cooler and Windows. Defaults to -1.
+	// Disabled with -1.
+	Lease time.Duration
+	// Queue is the maximum amount of actions allowed to wait for an execution
+	// slot once Budget actions are executing already. Waiting actions are
+	// admitted by priority class first, see WithPriority, and fairly across
+	// keys within a priority class, see WithFairKey. Once the queue is full,
+	// the most recent waiting action of the lowest priority class below the
+	// priority class of a new action is rejected with Filled in favour of the
+	// new action. Actions waiting in the queue only count towards the time
+	// windows defined by Cooler and Windows once they start executing, and
+	// keep waiting until they fit into every window. Defaults to 0, causing
+	// actions to be rejected with Filled immediately.
+	Queue uint
+	// Store is the optional StateStore persisting the admission times within
+	// the time window defined by Cooler and Windows, so that restarting
+	// processes, or processes running in parallel, do not exceed any window
+	// together. Only takes effect if Cooler or Windows is configured. The
+	// maximum amount of actions executing at the same time is always tracked
+	// per process.
+	Store StateStore
+	// Windows is the optional list of rate windows enforced in addition to
+	// the time window defined by Budget and Cooler, e.g. 10 actions per second
+	// and 500 actions per minute. Actions are only admitted if they fit into
+	// all windows at once. Actions rejected by one window do not count towards
+	// any other window.
+	Windows []Window
+	// Weights is the optional mapping of fair keys onto their share of the
+	// execution slots, relative to other keys of the same priority class
+	// waiting in the queue. Keys not configured have a weight of 1.
+	Weights map[string]uint
+}
+
+// Window is a rate window of a limiter, admitting at most Budget actions
+// within any period of Cooler.
+type Window struct {
+	// Budget is the maximum amount of actions admitted within Cooler.
+	Budget uint
+	// Cooler is the length of the window.
+	Cooler time.Duration
+	// Name is the optional name of the window reported by FilledError.Window.
+	// Defaults to Budget and Cooler, e.g. "10/1s".
+	Name string
+}
+
+// Policy returns the limiter policy of the limiter configuration, returning
+// Filled if the provided action cannot be admitted. Composing the limiter
+// policy outside of a retry policy limits calls, while composing it inside of
+// a retry policy limits attempts.
+func (l Limiter) Policy() Interface {
+	if l.Budget == 0 {
+		l.Budget = 3
+	}
+	if l.Cooler == 0 {
+		l.Cooler = -1
+	}
+	return l.New()
 }
 
 func (l *Limiter) New() *limiter {
+	if l.Key == "" {
+		l.Key = "limiter"
+	}
+	if l.Lease == 0 {
+		l.Lease = -1
+	}
+
+	var win []Window
+	if l.Cooler != -1 {
+		win = append(win, Window{Budget: l.Budget, Cooler: l.Cooler})
+	}
+
+	for _, w := range l.Windows {
+		if w.Budget == 0 {
+			panic(fmt.Sprintf("%T.Budget must not be empty", w))
+		}
+		if w.Cooler <= 0 {
+			panic(fmt.Sprintf("%T.Cooler must not be empty", w))
+		}
+
+		win = append(win, w)
+	}
+
+	// The log of admission times has to cover the longest window, which
+	// never admits more actions than its budget.
+	var ret time.Duration
+	var siz uint
+	for i := range win {
+		if win[i].Name == "" {
+			win[i].Name = fmt.Sprintf("%d/%s", win[i].Budget, win[i].Cooler)
+		}
+		if win[i].Cooler > ret {
+			ret = win[i].Cooler
+			siz = win[i].Budget
+		}
+	}
+
+	var tim []time.Time
+	if len(win) != 0 && l.Store == nil {
+		tim = make([]time.Time, 0, siz)
+	}
+
 	return &limiter{
-		bud: make(chan struct{}, l.Budget),
-		coo: l.Cooler,
-		que: make(chan struct{}, l.Budget),
+		bud: l.Budget,
+		key: l.Key,
+		lea: l.Lease,
+		que: l.Queue,
+		ret: ret,
+		sto: l.Store,
+		tim: tim,
+		wgt: l.Weights,
+		win: win,
 	}
 }
 
 type limiter struct {
-	bud chan struct{}
-	coo time.Duration
+	bud uint
+	// cla are the queues of waiting actions per priority class, from the
+	// highest to the lowest.
+	cla [len(priorities)]queue
+	key string
+	lea time.Duration
+	// lel are the leases of all actions executing at the moment, including the
+	// actions whose lease expired already.
+	lel map[*lease]struct{}
 	mut sync.Mutex
-	que chan struct{}
+	que uint
+	// ret is the retention of the log of admission times, which is the
+	// length of the longest window.
+	ret time.Duration
+	// run is the amount of actions executing at the moment.
+	run uint
+	sta [len(priorities)]QueueStats
+	sto StateStore
+	// tim is the log of admission times within the longest window, ordered
+	// from oldest to newest. tim is only tracked in memory if any window is
+	// configured without a StateStore.
 	tim []time.Time
+	// tmr is the timer handing execution slots over to waiting actions once
+	// the windows have room for the next one again.
+	tmr *time.Timer
+	wgt map[string]uint
+	win []Window
+}
+
+// Budget returns the maximum amount of actions allowed to be executed at the
+// same time.
+func (l *limiter) Budget() uint {
+	return l.bud
 }
 
 func (l *limiter) Execute(act func() error) error {
-	var dur time.Duration
-	var tim time.Time
-	{
-		l.mut.Lock()
-		if len(l.tim) != 0 {
-			tim = l.tim[0]
-			dur = time.Since(tim)
+	return l.execute(nil, 1, act)
+}
+
+// ExecuteContext executes act like Execute, while the priority class, the
+// fair key and the cost carried by ctx are applied to act. Waiting for an
+// execution slot stops once ctx is done, returning the error of ctx.
+func (l *limiter) ExecuteContext(ctx context.Context, act func() error) error {
+	return l.execute(ctx, cost(ctx), act)
+}
+
+// ExecuteCost executes act like Execute, while act reserves the given cost
+// from Budget, instead of a cost of 1, for as long as it executes, and within
+// every window. Oversized is returned if the cost exceeds Budget, or the
+// budget of any window, since such actions can never be admitted. A cost of
+// 0 is treated like a cost of 1.
+func (l *limiter) ExecuteCost(cos uint, act func() error) error {
+	return l.execute(nil, cos, act)
+}
+
+// QueueStats returns a snapshot of the counters of the limiter per priority
+// class.
+func (l *limiter) QueueStats() map[Priority]QueueStats {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	sta := map[Priority]QueueStats{}
+	for i, p := range priorities {
+		sta[p] = l.sta[i]
+	}
+
+	return sta
+}
+
+func (l *limiter) execute(ctx context.Context, cos uint, act func() error) error {
+	if cos == 0 {
+		cos = 1
+	}
+
+	if cos > l.bud {
+		return tracer.Maskf(Oversized, "cost %d exceeds budget %d", cos, l.bud)
+	}
+
+	for _, w := range l.win {
+		if cos > w.Budget {
+			return tracer.Maskf(Oversized, "cost %d exceeds window %s", cos, w.Name)
+		}
+	}
+
+	err := l.admit(ctx, cos)
+	if err != nil {
+		return err
+	}
+
+	if l.lea != -1 {
+		lea := l.hold(cos)
+		defer l.free(lea)
+	} else {
+		defer l.release(cos)
+	}
+
+	return act()
+}
+
+// admit decides about the admission of a single action under the limiter's
+// lock, so that concurrent callers can never exceed the budget together, and
+// never block on one another for longer than that decision, unless they have
+// to wait in the queue. Actions are rejected if they do not fit into every
+// window when they arrive. Actions waiting in the queue are checked against
+// the windows again once they start, see handover. Admitted actions must call
+// release once they finished.
+func (l *limiter) admit(ctx context.Context, cos uint) error {
+	if l.sto != nil && len(l.win) != 0 {
+		return l.persisted(ctx, cos)
+	}
+
+	pri, _ := scheduling(ctx)
+
+	l.mut.Lock()
+
+	var now time.Time
+	if len(l.win) != 0 {
+		now = time.Now().UTC()
+
+		var err error
+		l.tim, err = l.throttle(l.tim, now, cos)
+		if err != nil {
+			l.sta[pri.class()].Shed++
+			l.mut.Unlock()
+
+			return tracer.Mask(err)
+		}
+	}
+
+	return l.acquire(ctx, cos, func() {
+		for i := uint(0); len(l.win) != 0 && i < cos; i++ {
+			l.tim = append(l.tim, now)
+		}
+	})
+}
+
+// throttle drops all admission times that fell out of the longest window from
+// the given log, while reusing the underlying array of the log. FilledError
+// is returned if an action of the given cost does not fit into every window.
+// The error names the window that takes the longest to admit the action.
+func (l *limiter) throttle(tim []time.Time, now time.Time, cos uint) ([]time.Time, error) {
+	var i int
+	for i < len(tim) && !tim[i].Add(l.ret).After(now) {
+		i++
+	}
+
+	tim = tim[:copy(tim, tim[i:])]
+
+	var del time.Duration
+	var win *Window
+	for j := range l.win {
+		w := &l.win[j]
+
+		beg := sort.Search(len(tim), func(k int) bool { return tim[k].Add(w.Cooler).After(now) })
+		cou := uint(len(tim) - beg)
+
+		if cou+cos <= w.Budget {
+			continue
+		}
+
+		// The oldest admission times of the window have to fall out of it
+		// until the cost of the action fits into it.
+		d := tim[beg+int(cou+cos-w.Budget)-1].Add(w.Cooler).Sub(now)
+		if win == nil || d > del {
+			del = d
+			win = w
 		}
+	}
+
+	if win != nil {
+		return tim, tracer.Mask(&FilledError{
+			Anno:   fmt.Sprintf("actions throttled by window %s for another %s", win.Name, del),
+			Delay:  del,
+			Reset:  now.Add(del),
+			Window: win.Name,
+		})
+	}
+
+	return tim, nil
+}
+
+// acquire claims an execution slot, if one is free, or otherwise waits in the
+// queue for one to be released. acquire must be called with the limiter's
+// lock held, and releases it. res is called with the lock held if the action
+// got admitted right away.
+func (l *limiter) acquire(ctx context.Context, cos uint, res func()) error {
+	pri, key := scheduling(ctx)
+
+	cla := pri.class()
+
+	// Actions are only admitted right away if no other action is waiting, so
+	// that actions of high cost cannot be starved by actions of low cost.
+	if l.run+cos <= l.bud && l.waiting() == 0 {
+		res()
+		l.run += cos
+		l.sta[cla].Admitted++
 		l.mut.Unlock()
+
+		return nil
 	}
 
+	if l.que == 0 {
+		run := l.run
+
+		l.sta[cla].Shed++
+		l.mut.Unlock()
+
+		return tracer.Mask(&FilledError{
+			Anno: fmt.Sprintf("%d actions already queued", run),
+		})
+	}
+
+	if l.waiting() >= l.que && !l.shed(cla) {
+		wai := l.waiting()
+
+		l.sta[cla].Shed++
+		l.mut.Unlock()
+
+		return tracer.Mask(&FilledError{
+			Anno: fmt.Sprintf("%d actions already waiting", wai),
+		})
+	}
+
+	var w *waiter
 	{
-		if len(l.bud) == cap(l.bud) && dur >= l.coo {
-			{
-				l.mut.Lock()
-			}
+		w = &waiter{
+			cos: cos,
+			key: key,
+			rdy: make(chan error, 1),
+			sta: time.Now(),
+		}
+	}
 
-			{
-				for _, t := range l.tim {
-					if t.Add(l.coo).Before(time.Now().UTC()) {
-						{
-							<-l.bud
-						}
-
-						{
-							l.tim = l.tim[1:]
-						}
-					}
-				}
-			}
+	wgt, ok := l.wgt[key]
+	if !ok || wgt == 0 {
+		wgt = 1
+	}
+
+	l.cla[cla].Push(w, wgt)
+	l.mut.Unlock()
+
+	var don <-chan struct{}
+	if ctx != nil {
+		don = ctx.Done()
+	}
+
+	select {
+	case err := <-w.rdy:
+		return err
+	case <-don:
+		var rem bool
+		{
+			l.mut.Lock()
+			rem = l.cla[cla].Remove(w)
+			l.mut.Unlock()
+		}
 
-			{
-				l.mut.Unlock()
+		// The slot might have been handed over in the meantime, in which case
+		// it has to be released again.
+		if !rem {
+			err := <-w.rdy
+			if err == nil {
+				l.release(cos)
 			}
 		}
+
+		return tracer.Mask(ctx.Err())
 	}
+}
 
-	{
-		if l.coo != -1 && !tim.IsZero() && len(l.bud) == cap(l.bud) {
-			return tracer.Maskf(Filled, "actions throttled for another %s", l.coo-dur)
+// shed rejects the most recent waiting action of the lowest priority class
+// below the given one, if any.
+func (l *limiter) shed(cla int) bool {
+	for i := len(l.cla) - 1; i > cla; i-- {
+		q := &l.cla[i]
+		if len(q.wai) == 0 {
+			continue
 		}
+
+		w := q.wai[len(q.wai)-1]
+		q.Remove(w)
+
+		l.sta[i].Shed++
+
+		w.rdy <- tracer.Mask(&FilledError{
+			Anno: fmt.Sprintf("action shed in favour of priority %s", priorities[cla]),
+		})
+
+		return true
 	}
 
-	{
-		if len(l.que) == cap(l.que) {
-			return tracer.Maskf(Filled, "%d actions already queued", len(l.que))
-		}
+	return false
+}
+
+// waiting returns the amount of actions waiting in the queue.
+func (l *limiter) waiting() uint {
+	var wai uint
+	for i := range l.cla {
+		wai += uint(len(l.cla[i].wai))
 	}
 
+	return wai
+}
+
+// persisted decides about the admission like admit, while the admission times
+// within the windows are tracked using the configured StateStore. The
+// execution slot is claimed before consulting the store, and given back if
+// the store rejects the action.
+func (l *limiter) persisted(ctx context.Context, cos uint) error {
 	{
 		l.mut.Lock()
-		l.tim = append(l.tim, time.Now().UTC())
-		l.mut.Unlock()
+
+		err := l.acquire(ctx, cos, func() {})
+		if err != nil {
+			return tracer.Mask(err)
+		}
 	}
 
-	{
-		l.bud <- struct{}{}
-		l.que <- struct{}{}
+	err := l.sto.Update(l.key, func(sta *State) error {
+		var now time.Time
+		{
+			now = time.Now().UTC()
+		}
+
+		cur, err := l.throttle(sta.Limiter, now, cos)
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		{
+			for i := uint(0); i < cos; i++ {
+				cur = append(cur, now)
+			}
+
+			sta.Limiter = cur
+		}
+
+		return nil
+	})
+	if err != nil {
+		l.release(cos)
+		return tracer.Mask(err)
 	}
 
-	{
-		defer func() { <-l.que }()
+	return nil
+}
+
+// release gives back the cost of an admitted action, handing the freed budget
+// over to the waiting actions, as long as the next one fits.
+func (l *limiter) release(cos uint) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.handover(cos)
+}
+
+// handover gives back the given cost like release, while the limiter's lock
+// must be held already. Waiting actions only start if they fit into every
+// window tracked in memory at that moment. Otherwise they keep waiting until
+// the window that takes the longest to admit them has room again.
+func (l *limiter) handover(cos uint) {
+	l.run -= cos
+
+	for {
+		var cla int
+		for cla < len(l.cla) && len(l.cla[cla].wai) == 0 {
+			cla++
+		}
+
+		if cla == len(l.cla) || l.run+l.cla[cla].wai[0].cos > l.bud {
+			return
+		}
+
+		if l.sto == nil && len(l.win) != 0 {
+			now := time.Now().UTC()
+
+			var err error
+			l.tim, err = l.throttle(l.tim, now, l.cla[cla].wai[0].cos)
+			if err != nil {
+				var fil *FilledError
+				if l.tmr == nil && errors.As(err, &fil) {
+					l.tmr = time.AfterFunc(fil.Delay, l.wake)
+				}
+
+				return
+			}
+
+			for i := uint(0); i < l.cla[cla].wai[0].cos; i++ {
+				l.tim = append(l.tim, now)
+			}
+		}
+
+		w := l.cla[cla].Pop()
+
+		wai := time.Since(w.sta)
+
+		l.run += w.cos
+
+		l.sta[cla].Admitted++
+		l.sta[cla].Queued++
+		l.sta[cla].Wait += wai
+		if wai > l.sta[cla].WaitMax {
+			l.sta[cla].WaitMax = wai
+		}
+
+		w.rdy <- nil
 	}
+}
 
-	return act()
+// wake hands execution slots over to waiting actions once the windows have
+// room again.
+func (l *limiter) wake() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.tmr = nil
+	l.handover(0)
+}
+
+func (l *limiter) Wrapper(act func() error) func() error {
+	return func() error {
+		err := l.Execute(act)
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		return nil
+	}
+}
+
+func (l *limiter) apply(ctx context.Context, act func(ctx context.Context) error) error {
+	return l.ExecuteContext(ctx, func() error { return act(ctx) })
 }
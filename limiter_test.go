@@ -0,0 +1,275 @@
+package breakr
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Limiter_Stress(t *testing.T) {
+	testCases := []struct {
+		bud uint
+		coo time.Duration
+	}{
+		// Case 0 ensures that concurrent callers never exceed the budget of
+		// actions executing at the same time.
+		{
+			bud: 4,
+			coo: -1,
+		},
+		// Case 1 ensures that concurrent callers never exceed the budget of
+		// actions within the time window.
+		{
+			bud: 4,
+			coo: 20 * time.Millisecond,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var l *limiter
+			{
+				c := Limiter{
+					Budget: tc.bud,
+					Cooler: tc.coo,
+				}
+
+				l = c.New()
+			}
+
+			var cur atomic.Int64
+			var max atomic.Int64
+			var adm atomic.Int64
+			var lat atomic.Int64
+
+			act := func() error {
+				c := cur.Add(1)
+				for {
+					m := max.Load()
+					if c <= m || max.CompareAndSwap(m, c) {
+						break
+					}
+				}
+
+				adm.Add(1)
+				time.Sleep(time.Millisecond)
+				cur.Add(-1)
+
+				return nil
+			}
+
+			var sta time.Time
+			{
+				sta = time.Now()
+			}
+
+			var wg sync.WaitGroup
+			for j := 0; j < 32; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					for k := 0; k < 50; k++ {
+						var beg time.Time
+						var dur time.Duration
+						{
+							beg = time.Now()
+						}
+
+						err := l.Execute(func() error {
+							dur = time.Since(beg)
+							return act()
+						})
+						if err != nil && !IsFilled(err) {
+							t.Errorf("expected Filled, got %#v", err)
+							return
+						}
+						if err != nil {
+							dur = time.Since(beg)
+						}
+
+						// Admission is a single decision, so callers must never
+						// wait for other actions to finish.
+						for {
+							m := lat.Load()
+							if int64(dur) <= m || lat.CompareAndSwap(m, int64(dur)) {
+								break
+							}
+						}
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			if max.Load() > int64(tc.bud) {
+				t.Fatalf("expected at most %d actions at the same time, got %d", tc.bud, max.Load())
+			}
+
+			if lat.Load() > int64(50*time.Millisecond) {
+				t.Fatalf("expected admission to never block, took %s", time.Duration(lat.Load()))
+			}
+
+			if tc.coo != -1 {
+				win := int64(time.Since(sta)/tc.coo) + 1
+				if adm.Load() > win*int64(tc.bud) {
+					t.Fatalf("expected at most %d actions, got %d", win*int64(tc.bud), adm.Load())
+				}
+			}
+
+			if l.run != 0 {
+				t.Fatalf("expected all slots to be released, got %d", l.run)
+			}
+		})
+	}
+}
+
+func Test_Limiter_Windows(t *testing.T) {
+	testCases := []struct {
+		sto func(t *testing.T) StateStore
+	}{
+		// Case 0 ensures that all windows are enforced in memory.
+		{
+			sto: func(t *testing.T) StateStore { return nil },
+		},
+		// Case 1 ensures that all windows are enforced using a StateStore.
+		{
+			sto: func(t *testing.T) StateStore { return NewFileStore(t.TempDir()) },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var l *limiter
+			{
+				c := Limiter{
+					Budget: 10,
+					Cooler: -1,
+					Store:  tc.sto(t),
+					Windows: []Window{
+						{Budget: 2, Cooler: 100 * time.Millisecond},
+						{Budget: 3, Cooler: 5 * time.Second, Name: "slow"},
+					},
+				}
+
+				l = c.New()
+			}
+
+			act := func() error { return nil }
+
+			for j := 0; j < 2; j++ {
+				err := l.Execute(act)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var fil *FilledError
+
+			err := l.Execute(act)
+			if !errors.As(err, &fil) {
+				t.Fatalf("expected FilledError, got %#v", err)
+			}
+			if fil.Window != "2/100ms" {
+				t.Fatalf("win\n\n%s\n", cmp.Diff("2/100ms", fil.Window))
+			}
+			if fil.Delay <= 0 || fil.Delay > 100*time.Millisecond {
+				t.Fatalf("unexpected delay %s", fil.Delay)
+			}
+
+			time.Sleep(fil.Delay + 10*time.Millisecond)
+
+			err = l.Execute(act)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The fast window has room again, while the slow window is used up,
+			// regardless of the action rejected before.
+			err = l.Execute(act)
+			if !errors.As(err, &fil) {
+				t.Fatalf("expected FilledError, got %#v", err)
+			}
+			if fil.Window != "slow" {
+				t.Fatalf("win\n\n%s\n", cmp.Diff("slow", fil.Window))
+			}
+			if fil.Delay <= 4*time.Second || fil.Delay > 5*time.Second {
+				t.Fatalf("unexpected delay %s", fil.Delay)
+			}
+
+			err = l.ExecuteCost(4, act)
+			if !IsOversized(err) {
+				t.Fatalf("expected Oversized, got %#v", err)
+			}
+		})
+	}
+}
+
+func Test_Limiter_Windows_Queue(t *testing.T) {
+	var l *limiter
+	{
+		c := Limiter{
+			Budget: 1,
+			Cooler: -1,
+			Queue:  4,
+			Windows: []Window{
+				{Budget: 2, Cooler: 300 * time.Millisecond},
+			},
+		}
+
+		l = c.New()
+	}
+
+	var mut sync.Mutex
+	var sta []time.Time
+
+	act := func() error {
+		mut.Lock()
+		sta = append(sta, time.Now())
+		mut.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := l.Execute(act)
+			if err != nil {
+				t.Errorf("expected no error, got %#v", err)
+			}
+		}()
+
+		// The first action has to be admitted before the others are queued.
+		if i == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	wg.Wait()
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if len(sta) != 4 {
+		t.Fatalf("sta\n\n%s\n", cmp.Diff(4, len(sta)))
+	}
+
+	// Queued actions must only count towards the window once they start, so
+	// that no two actions start within the same window as an earlier one.
+	for i := 2; i < len(sta); i++ {
+		if sta[i].Sub(sta[i-2]) < 290*time.Millisecond {
+			t.Fatalf("expected at most 2 actions within 300ms, got %s between action %d and %d", sta[i].Sub(sta[i-2]), i-2, i)
+		}
+	}
+}
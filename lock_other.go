@@ -0,0 +1,15 @@
+//go:build !unix
+
+package breakr
+
+import "os"
+
+// lock is a no-op on platforms without flock. Updates of a FileStore are then
+// only serialized within a single process.
+func lock(fil *os.File) error {
+	return nil
+}
+
+func unlock(fil *os.File) error {
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+package breakr
+
+import (
+	"os"
+	"syscall"
+)
+
+func lock(fil *os.File) error {
+	return syscall.Flock(int(fil.Fd()), syscall.LOCK_EX)
+}
+
+func unlock(fil *os.File) error {
+	return syscall.Flock(int(fil.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,120 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// Event is the type of a log record emitted by Breakr.
+type Event string
+
+const (
+	// EventCooldown is emitted whenever the execution loop waits before the
+	// next attempt. Defaults to slog.LevelDebug.
+	EventCooldown Event = "cooldown"
+	// EventFilled is emitted whenever the limiter rejected an attempt.
+	// Defaults to slog.LevelWarn.
+	EventFilled Event = "filled"
+	// EventGiveUp is emitted whenever the execution loop returns an error,
+	// unless the limiter rejected the attempt. Defaults to slog.LevelError.
+	EventGiveUp Event = "giveup"
+	// EventRetry is emitted whenever an attempt failed and gets retried.
+	// Defaults to slog.LevelInfo.
+	EventRetry Event = "retry"
+	// EventTimeout is emitted whenever an attempt timed out. Defaults to
+	// slog.LevelWarn.
+	EventTimeout Event = "timeout"
+)
+
+type Logger struct {
+	// Handler is the optional slog handler receiving structured records about
+	// the execution loop. Every record carries the attributes breaker, event,
+	// attempt and elapsed. Records about errors carry the attributes error and
+	// kind, the latter being the Kind of the closest tracer.Error. Records
+	// about waits carry the attribute delay. Logging is disabled if Handler is
+	// nil.
+	Handler slog.Handler
+	// Levels is the optional mapping of events onto the level they are logged
+	// with. Events not configured are logged with their default level.
+	Levels map[Event]slog.Level
+	// Name is the name of the breaker attached to every record as attribute
+	// breaker.
+	Name string
+}
+
+var defaultLevels = map[Event]slog.Level{
+	EventCooldown: slog.LevelDebug,
+	EventFilled:   slog.LevelWarn,
+	EventGiveUp:   slog.LevelError,
+	EventRetry:    slog.LevelInfo,
+	EventTimeout:  slog.LevelWarn,
+}
+
+// emit emits a record for the given event, if logging is enabled for the
+// level of the event. The attributes of the optional error are only computed
+// if the record gets emitted.
+func (l *Logger) emit(eve Event, ano uint, sta time.Time, err error, att ...slog.Attr) {
+	if l.Handler == nil {
+		return
+	}
+
+	lev, ok := l.Levels[eve]
+	if !ok {
+		lev = defaultLevels[eve]
+	}
+
+	if !l.Handler.Enabled(context.Background(), lev) {
+		return
+	}
+
+	rec := slog.NewRecord(time.Now(), lev, "breakr "+string(eve), 0)
+	rec.AddAttrs(
+		slog.String("breaker", l.Name),
+		slog.String("event", string(eve)),
+		slog.Uint64("attempt", uint64(ano)),
+		slog.Duration("elapsed", time.Since(sta)),
+	)
+	if err != nil {
+		rec.AddAttrs(errorAttrs(err)...)
+	}
+	rec.AddAttrs(att...)
+
+	_ = l.Handler.Handle(context.Background(), rec)
+}
+
+func errorAttrs(err error) []slog.Attr {
+	return []slog.Attr{
+		slog.String("error", err.Error()),
+		slog.String("kind", kind(err)),
+	}
+}
+
+// kind returns the Kind of the closest tracer.Error found in the chain of the
+// given error that has a Kind. Errors like FilledError, which only match one
+// of the errors of this package, report the Kind of the error they match.
+func kind(err error) string {
+	for cur := err; cur != nil; {
+		var tra *tracer.Error
+		if !errors.As(cur, &tra) {
+			break
+		}
+
+		if tra.Kind != "" {
+			return tra.Kind
+		}
+
+		cur = tra.Unwrap()
+	}
+
+	for _, x := range []*tracer.Error{Cancel, Closed, Filled, Oversized, Passed, Pending, Repeat} {
+		if errors.Is(err, x) {
+			return x.Kind
+		}
+	}
+
+	return ""
+}
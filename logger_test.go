@@ -0,0 +1,112 @@
+package breakr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/tracer"
+)
+
+func Test_Breakr_Logger(t *testing.T) {
+	var testError = &tracer.Error{
+		Kind: "testError",
+	}
+
+	var han *testHandler
+	{
+		han = &testHandler{}
+	}
+
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Budget: 2,
+				Cooler: time.Millisecond,
+			},
+			Limiter: Limiter{
+				Budget: 2,
+				Cooler: time.Minute,
+			},
+			Logger: Logger{
+				Handler: han,
+				Levels: map[Event]slog.Level{
+					EventRetry: slog.LevelWarn,
+				},
+				Name: "test",
+			},
+		})
+	}
+
+	{
+		err := b.Execute(func() error { return tracer.Mask(testError) })
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	{
+		err := b.Execute(func() error { return nil })
+		if !IsFilled(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+	}
+
+	exp := []string{
+		"WARN retry breaker=test attempt=1 kind=testError",
+		"DEBUG cooldown breaker=test attempt=1 kind=",
+		"ERROR giveup breaker=test attempt=2 kind=testError",
+		"WARN filled breaker=test attempt=1 kind=filled",
+	}
+
+	if !cmp.Equal(exp, han.Records()) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(exp, han.Records()))
+	}
+}
+
+type testHandler struct {
+	mut sync.Mutex
+	rec []string
+}
+
+func (h *testHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *testHandler) Handle(_ context.Context, r slog.Record) error {
+	var att map[string]string
+	{
+		att = map[string]string{}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		att[a.Key] = a.Value.String()
+		return true
+	})
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	h.rec = append(h.rec, fmt.Sprintf("%s %s breaker=%s attempt=%s kind=%s", r.Level, att["event"], att["breaker"], att["attempt"], att["kind"]))
+
+	return nil
+}
+
+func (h *testHandler) Records() []string {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return append([]string(nil), h.rec...)
+}
+
+func (h *testHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *testHandler) WithGroup(string) slog.Handler {
+	return h
+}
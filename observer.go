@@ -0,0 +1,15 @@
+package breakr
+
+type Observer struct {
+	// Fallback is the optional hook called every time Breakr.ExecuteWithFallback
+	// gives up on the provided action and hands control over to the provided
+	// fallback. The hook receives the error that caused the fallback, which can
+	// be classified using IsCancel, IsClosed, IsFilled or IsPassed.
+	Fallback func(err error)
+	// Panic is the optional hook called every time an attempt panicked and got
+	// recovered, given that Failure.Recover is enabled.
+	Panic func(err *PanicError)
+	// Run is the optional hook called by Scheduler with the outcome of every
+	// run, including runs that got skipped.
+	Run func(run Run)
+}
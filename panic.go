@@ -0,0 +1,64 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// PanicError is the error returned by attempts that panicked, given that
+// Failure.Recover is enabled.
+type PanicError struct {
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+	// Value is the value the action panicked with.
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func IsPanic(err error) bool {
+	var pan *PanicError
+	return errors.As(err, &pan)
+}
+
+// recoverer is carried by the context of executions recovering panics of the
+// provided action, see Failure.Recover.
+type recoverer struct {
+	// obs is the optional hook called with every panic recovered.
+	obs func(err *PanicError)
+}
+
+// recovered executes act and turns any panic of act into PanicError, if ctx
+// carries a recoverer.
+func recovered(ctx context.Context, act func(ctx context.Context) error) (err error) {
+	rec, _ := ctx.Value(recoverKey).(*recoverer)
+	if rec == nil {
+		return act(ctx)
+	}
+
+	defer func() {
+		val := recover()
+		if val == nil {
+			return
+		}
+
+		pan := &PanicError{
+			Stack: debug.Stack(),
+			Value: val,
+		}
+
+		if rec.obs != nil {
+			rec.obs(pan)
+		}
+
+		err = tracer.Mask(pan)
+	}()
+
+	return act(ctx)
+}
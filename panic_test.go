@@ -0,0 +1,92 @@
+package breakr
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Breakr_Panic(t *testing.T) {
+	testCases := []struct {
+		cla func(err error) error
+		cou uint
+		mat func(err error) bool
+	}{
+		// Case 0 ensures that recovered panics are retried like any other
+		// error by default.
+		{
+			cla: nil,
+			cou: 3,
+			mat: IsPanic,
+		},
+		// Case 1 ensures that the classifier can prevent panics from being
+		// retried.
+		{
+			cla: func(err error) error {
+				if IsPanic(err) {
+					return errors.Join(Cancel, err)
+				}
+
+				return err
+			},
+			cou: 1,
+			mat: func(err error) bool { return IsPanic(err) && IsCancel(err) },
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var cou *counter
+			{
+				cou = &counter{}
+			}
+
+			var mut sync.Mutex
+			var pan []*PanicError
+
+			var b *Breakr
+			{
+				b = New(Config{
+					Failure: Failure{
+						Budget:   3,
+						Classify: tc.cla,
+						Cooler:   -1,
+						Recover:  true,
+					},
+					Observer: Observer{
+						Panic: func(err *PanicError) {
+							mut.Lock()
+							pan = append(pan, err)
+							mut.Unlock()
+						},
+					},
+				})
+			}
+
+			err := b.Execute(func() error {
+				cou.Inc()
+				panic("test panic")
+			})
+			if !tc.mat(err) {
+				t.Fatalf("expected error matcher to match")
+			}
+
+			if cou.Cou() != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, cou.Cou()))
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			if uint(len(pan)) != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, uint(len(pan))))
+			}
+			if pan[0].Value != "test panic" || len(pan[0].Stack) == 0 {
+				t.Fatalf("expected panic value and stack to be recorded")
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package breakr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+type Poller struct {
+	// Consecutive is the amount of consecutive checks the condition provided to
+	// Breakr.Poll has to hold before Breakr.Poll returns. Any check in which the
+	// condition does not hold resets the count. Defaults to 1.
+	Consecutive uint
+	// Interval is the time to wait between checks of the condition provided to
+	// Breakr.Poll, as well as the time to wait after any action returned
+	// Pending. Defaults to 1s. Disabled with -1.
+	Interval time.Duration
+}
+
+// Poll checks cond until it holds Poller.Consecutive times in a row. Checks in
+// which cond does not hold yet are repeated after Poller.Interval without
+// taking away from the failure budget. Errors returned by cond are handled
+// like errors returned by actions provided to Breakr.Execute, e.g. they use
+// up the failure budget, and Cancel stops polling immediately.
+func (b *Breakr) Poll(cond func() (bool, error)) error {
+	var cou uint
+	var mut sync.Mutex
+
+	err := b.Execute(func() error {
+		mut.Lock()
+		defer mut.Unlock()
+
+		ok, err := cond()
+		if err != nil {
+			cou = 0
+			return tracer.Mask(err)
+		}
+
+		if !ok {
+			cou = 0
+			return tracer.Mask(Pending)
+		}
+
+		cou++
+		if cou < b.pol.Consecutive {
+			return tracer.Mask(Pending)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
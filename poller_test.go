@@ -0,0 +1,95 @@
+package breakr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xh3b4sd/tracer"
+)
+
+func Test_Breakr_Poll(t *testing.T) {
+	var testError = &tracer.Error{
+		Kind: "testError",
+	}
+
+	testCases := []struct {
+		res []error
+		con uint
+		cou int
+		mat func(err error) bool
+	}{
+		// Case 0 ensures that checks which do not hold yet do not use up the
+		// failure budget.
+		{
+			res: []error{Pending, Pending, Pending, nil},
+			con: 1,
+			cou: 4,
+			mat: func(err error) bool { return err == nil },
+		},
+		// Case 1 ensures that the condition has to hold consecutively.
+		{
+			res: []error{nil, Pending, nil, nil, nil},
+			con: 3,
+			cou: 5,
+			mat: func(err error) bool { return err == nil },
+		},
+		// Case 2 ensures that errors use up the failure budget.
+		{
+			res: []error{Pending, testError, testError, nil},
+			con: 1,
+			cou: 3,
+			mat: func(err error) bool { return errors.Is(err, testError) },
+		},
+		// Case 3 ensures that Cancel stops polling immediately.
+		{
+			res: []error{Pending, Cancel, nil},
+			con: 1,
+			cou: 2,
+			mat: IsCancel,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var b *Breakr
+			{
+				b = New(Config{
+					Failure: Failure{
+						Budget: 2,
+						Cooler: -1,
+					},
+					Poller: Poller{
+						Consecutive: tc.con,
+						Interval:    10 * time.Millisecond,
+					},
+				})
+			}
+
+			var cou int
+
+			err := b.Poll(func() (bool, error) {
+				res := tc.res[cou]
+				cou++
+
+				if IsPending(res) {
+					return false, nil
+				}
+				if res != nil {
+					return false, res
+				}
+
+				return true, nil
+			})
+			if !tc.mat(err) {
+				t.Fatalf("expected error matcher to match")
+			}
+
+			if cou != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, cou))
+			}
+		})
+	}
+}
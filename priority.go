@@ -0,0 +1,184 @@
+package breakr
+
+import (
+	"context"
+	"time"
+)
+
+// Priority is the priority class of an action competing for the execution
+// slots of a limiter. Waiting actions of higher priority classes are admitted
+// first, while waiting actions of lower priority classes are shed first once
+// the queue of the limiter is full. Priorities are attached to actions using
+// WithPriority.
+type Priority int
+
+const (
+	// PrioritySheddable is the priority class of actions that can be dropped
+	// first under contention, e.g. batch jobs.
+	PrioritySheddable Priority = -1
+	// PriorityDefault is the priority class of all actions that do not carry
+	// any priority class.
+	PriorityDefault Priority = 0
+	// PriorityCritical is the priority class of actions admitted before any
+	// other action, e.g. interactive requests.
+	PriorityCritical Priority = 1
+)
+
+func (p Priority) String() string {
+	switch p.class() {
+	case 0:
+		return "critical"
+	case 2:
+		return "sheddable"
+	}
+
+	return "default"
+}
+
+// class returns the index of the priority class, from the highest to the
+// lowest. Priorities out of range are treated like the closest class.
+func (p Priority) class() int {
+	if p >= PriorityCritical {
+		return 0
+	}
+	if p <= PrioritySheddable {
+		return 2
+	}
+
+	return 1
+}
+
+var priorities = [...]Priority{PriorityCritical, PriorityDefault, PrioritySheddable}
+
+// QueueStats are the counters of a limiter for a single priority class.
+type QueueStats struct {
+	// Admitted is the amount of actions that got admitted, either immediately
+	// or after waiting in the queue.
+	Admitted uint64
+	// Queued is the amount of admitted actions that waited in the queue.
+	Queued uint64
+	// Shed is the amount of actions rejected with Filled, either immediately
+	// or after waiting in the queue.
+	Shed uint64
+	// Wait is the total time admitted actions waited in the queue.
+	Wait time.Duration
+	// WaitMax is the longest time an admitted action waited in the queue.
+	WaitMax time.Duration
+}
+
+type contextKey int
+
+const (
+	costKey contextKey = iota
+	fairKey
+	priorityKey
+	progressKey
+	recoverKey
+)
+
+// WithFairKey returns a copy of ctx carrying the given key, e.g. the name of a
+// tenant. Actions waiting in the queue of a limiter are admitted fairly across
+// keys of the same priority class, according to Limiter.Weights.
+func WithFairKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, fairKey, key)
+}
+
+// WithPriority returns a copy of ctx carrying the given priority class, which
+// the limiter applies to actions executed using ctx.
+func WithPriority(ctx context.Context, pri Priority) context.Context {
+	return context.WithValue(ctx, priorityKey, pri)
+}
+
+// scheduling returns the priority class and the fair key carried by ctx.
+func scheduling(ctx context.Context) (Priority, string) {
+	if ctx == nil {
+		return PriorityDefault, ""
+	}
+
+	pri, _ := ctx.Value(priorityKey).(Priority)
+	key, _ := ctx.Value(fairKey).(string)
+
+	return pri, key
+}
+
+// waiter is an action waiting in the queue of a limiter.
+type waiter struct {
+	cos uint
+	key string
+	rdy chan error
+	sta time.Time
+	// tag is the virtual finish time of the waiter within its priority class.
+	tag float64
+}
+
+// queue are the waiters of a single priority class, ordered by their virtual
+// finish times. Every key advances its virtual finish time by the cost of
+// each waiter divided by the weight of the key, so that keys are served in
+// proportion to their weights, while keys that did not wait recently cannot
+// claim past service.
+type queue struct {
+	fin map[string]float64
+	vir float64
+	wai []*waiter
+}
+
+func (q *queue) Push(w *waiter, wgt uint) {
+	if q.fin == nil {
+		q.fin = map[string]float64{}
+	}
+
+	sta := q.vir
+	if f, ok := q.fin[w.key]; ok && f > sta {
+		sta = f
+	}
+
+	w.tag = sta + float64(w.cos)/float64(wgt)
+	q.fin[w.key] = w.tag
+
+	i := len(q.wai)
+	for i > 0 && q.wai[i-1].tag > w.tag {
+		i--
+	}
+
+	q.wai = append(q.wai, nil)
+	copy(q.wai[i+1:], q.wai[i:])
+	q.wai[i] = w
+}
+
+// Pop removes the waiter with the earliest virtual finish time.
+func (q *queue) Pop() *waiter {
+	w := q.wai[0]
+	q.wai = q.wai[:copy(q.wai, q.wai[1:])]
+	q.vir = w.tag
+
+	if q.fin[w.key] <= q.vir {
+		delete(q.fin, w.key)
+	}
+
+	return w
+}
+
+// Remove removes the given waiter, if it is still queued, and gives back the
+// service claimed by it to its key.
+func (q *queue) Remove(w *waiter) bool {
+	for i, x := range q.wai {
+		if x != w {
+			continue
+		}
+
+		q.wai = append(q.wai[:i], q.wai[i+1:]...)
+
+		if q.fin[w.key] == w.tag {
+			delete(q.fin, w.key)
+			for _, y := range q.wai {
+				if y.key == w.key && y.tag > q.fin[w.key] {
+					q.fin[w.key] = y.tag
+				}
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,273 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Limiter_Priority(t *testing.T) {
+	testCases := []struct {
+		pri []Priority
+		que uint
+		ord []string
+		fil []string
+	}{
+		// Case 0 ensures that waiting actions are admitted by priority class
+		// first, regardless of their order of arrival.
+		{
+			pri: []Priority{PrioritySheddable, PriorityDefault, PriorityCritical, PriorityDefault},
+			que: 10,
+			ord: []string{"critical-2", "default-1", "default-3", "sheddable-0"},
+			fil: nil,
+		},
+		// Case 1 ensures that waiting actions of lower priority classes are
+		// shed once the queue is full.
+		{
+			pri: []Priority{PrioritySheddable, PriorityDefault, PriorityCritical},
+			que: 2,
+			ord: []string{"critical-2", "default-1"},
+			fil: []string{"sheddable-0"},
+		},
+		// Case 2 ensures that new actions are rejected once the queue is full
+		// with actions of the same or higher priority classes.
+		{
+			pri: []Priority{PriorityCritical, PriorityDefault, PrioritySheddable},
+			que: 1,
+			ord: []string{"critical-0"},
+			fil: []string{"default-1", "sheddable-2"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var l *limiter
+			{
+				c := Limiter{
+					Budget: 1,
+					Cooler: -1,
+					Queue:  tc.que,
+				}
+
+				l = c.New()
+			}
+
+			blo := make(chan struct{})
+			run := make(chan struct{})
+			go func() {
+				_ = l.Execute(func() error {
+					close(run)
+					<-blo
+					return nil
+				})
+			}()
+			<-run
+
+			var mut sync.Mutex
+			var ord []string
+			var fil []string
+
+			var wg sync.WaitGroup
+			for j, p := range tc.pri {
+				nam := fmt.Sprintf("%s-%d", p, j)
+				ctx := WithPriority(context.Background(), p)
+
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					err := l.ExecuteContext(ctx, func() error {
+						mut.Lock()
+						ord = append(ord, nam)
+						mut.Unlock()
+						return nil
+					})
+					if IsFilled(err) {
+						mut.Lock()
+						fil = append(fil, nam)
+						mut.Unlock()
+					}
+				}()
+
+				// Every action has to either wait in the queue or be rejected
+				// before the next one arrives.
+				waitFor(t, func() bool {
+					l.mut.Lock()
+					defer l.mut.Unlock()
+
+					mut.Lock()
+					defer mut.Unlock()
+
+					return l.waiting()+uint(len(fil)) == uint(j+1)
+				})
+			}
+
+			close(blo)
+			wg.Wait()
+
+			if !cmp.Equal(tc.ord, ord) {
+				t.Fatalf("ord\n\n%s\n", cmp.Diff(tc.ord, ord))
+			}
+			if !cmp.Equal(tc.fil, fil) {
+				t.Fatalf("fil\n\n%s\n", cmp.Diff(tc.fil, fil))
+			}
+
+			var shd uint64
+			for _, s := range l.QueueStats() {
+				shd += s.Shed
+			}
+
+			if shd != uint64(len(tc.fil)) {
+				t.Fatalf("shd\n\n%s\n", cmp.Diff(uint64(len(tc.fil)), shd))
+			}
+		})
+	}
+}
+
+func Test_Limiter_Priority_Fair(t *testing.T) {
+	var l *limiter
+	{
+		c := Limiter{
+			Budget: 1,
+			Cooler: -1,
+			Queue:  100,
+			Weights: map[string]uint{
+				"a": 3,
+			},
+		}
+
+		l = c.New()
+	}
+
+	blo := make(chan struct{})
+	run := make(chan struct{})
+	go func() {
+		_ = l.Execute(func() error {
+			close(run)
+			<-blo
+			return nil
+		})
+	}()
+	<-run
+
+	var mut sync.Mutex
+	var ord []string
+
+	var wg sync.WaitGroup
+	for j, k := range []string{"a", "a", "a", "a", "a", "a", "a", "a", "b", "b", "b", "b", "b", "b", "b", "b"} {
+		key := k
+		ctx := WithFairKey(context.Background(), key)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_ = l.ExecuteContext(ctx, func() error {
+				mut.Lock()
+				ord = append(ord, key)
+				mut.Unlock()
+				return nil
+			})
+		}()
+
+		cou := uint(j + 1)
+		waitFor(t, func() bool {
+			l.mut.Lock()
+			defer l.mut.Unlock()
+			return l.waiting() == cou
+		})
+	}
+
+	close(blo)
+	wg.Wait()
+
+	// Key a has three times the weight of key b, so that key a is served
+	// three times as often as key b while both keys are waiting, although all
+	// actions of key a arrived first.
+	var cou int
+	for _, k := range ord[:8] {
+		if k == "a" {
+			cou++
+		}
+	}
+
+	if cou != 6 {
+		t.Fatalf("cou\n\n%s\n", cmp.Diff(6, cou))
+	}
+
+	sta := l.QueueStats()[PriorityDefault]
+	if sta.Queued != 16 {
+		t.Fatalf("que\n\n%s\n", cmp.Diff(uint64(16), sta.Queued))
+	}
+	if sta.Admitted != 17 {
+		t.Fatalf("adm\n\n%s\n", cmp.Diff(uint64(17), sta.Admitted))
+	}
+	if sta.WaitMax == 0 || sta.Wait < sta.WaitMax {
+		t.Fatalf("expected wait times to be tracked, got %#v", sta)
+	}
+}
+
+func Test_Limiter_Priority_Context(t *testing.T) {
+	var l *limiter
+	{
+		c := Limiter{
+			Budget: 1,
+			Cooler: -1,
+			Queue:  1,
+		}
+
+		l = c.New()
+	}
+
+	blo := make(chan struct{})
+	run := make(chan struct{})
+	go func() {
+		_ = l.Execute(func() error {
+			close(run)
+			<-blo
+			return nil
+		})
+	}()
+	<-run
+
+	ctx, can := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer can()
+
+	err := l.ExecuteContext(ctx, func() error {
+		t.Fatal("expected action not to be executed")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context error, got %#v", err)
+	}
+
+	if l.waiting() != 0 {
+		t.Fatalf("expected queue to be empty")
+	}
+
+	close(blo)
+
+	// The released slot must be usable again once the waiting action gave up.
+	waitFor(t, func() bool {
+		return l.Execute(func() error { return nil }) == nil
+	})
+}
+
+func waitFor(t *testing.T, con func() bool) {
+	t.Helper()
+
+	for i := 0; i < 200; i++ {
+		if con() {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("condition not met")
+}
@@ -0,0 +1,292 @@
+package breakr
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// Calendar defines the calendar unit Quota windows are aligned to.
+type Calendar int
+
+const (
+	// CalendarNone causes Quota to track a rolling window of the length
+	// defined by QuotaConfig.Interval.
+	CalendarNone Calendar = iota
+	// CalendarHour causes Quota to reset at the start of every hour.
+	CalendarHour
+	// CalendarDay causes Quota to reset at midnight.
+	CalendarDay
+	// CalendarWeek causes Quota to reset at midnight between Sunday and
+	// Monday.
+	CalendarWeek
+	// CalendarMonth causes Quota to reset at midnight of the first day of
+	// every month.
+	CalendarMonth
+)
+
+// window returns the start and the end of the calendar window containing the
+// given time, in the location of the given time.
+func (c Calendar) window(now time.Time) (time.Time, time.Time) {
+	y, m, d := now.Date()
+
+	switch c {
+	case CalendarHour:
+		sta := time.Date(y, m, d, now.Hour(), 0, 0, 0, now.Location())
+		return sta, sta.Add(time.Hour)
+	case CalendarWeek:
+		sta := time.Date(y, m, d-(int(now.Weekday())+6)%7, 0, 0, 0, 0, now.Location())
+		return sta, sta.AddDate(0, 0, 7)
+	case CalendarMonth:
+		sta := time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+		return sta, sta.AddDate(0, 1, 0)
+	}
+
+	sta := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	return sta, sta.AddDate(0, 0, 1)
+}
+
+type QuotaConfig struct {
+	// Budget is the maximum amount of actions allowed to be executed within a
+	// single window, e.g. 10,000 for an API allowing 10,000 calls per day.
+	Budget uint
+	// Calendar is the optional calendar unit windows are aligned to. Windows
+	// are rolling if Calendar is not configured. Defaults to CalendarNone.
+	Calendar Calendar
+	// Interval is the length of rolling windows. The amount of actions
+	// executed within a rolling window is approximated by weighting the count
+	// of the previous window by its share of the rolling window. Interval is
+	// ignored if Calendar is configured. Defaults to 24 hours.
+	Interval time.Duration
+	// Key is the name under which the quota state is persisted using Store.
+	// Quotas sharing the same Store and Key share their counters. Defaults to
+	// "quota".
+	Key string
+	// Location is the time zone calendar windows are aligned in. Defaults to
+	// time.UTC.
+	Location *time.Location
+	// Store is the optional StateStore persisting the counters of the quota,
+	// so that restarting processes, or processes running in parallel, do not
+	// exceed Budget within a window together. Defaults to a StateStore kept
+	// in memory.
+	Store StateStore
+}
+
+// QuotaUsage describes the usage of a Quota at a given point in time.
+type QuotaUsage struct {
+	// Remaining is the amount of actions that can be executed within the
+	// current window.
+	Remaining uint
+	// Reset is the time at which the next action can be executed, if
+	// Remaining is 0, or the end of the current window otherwise.
+	Reset time.Time
+	// Used is the amount of actions executed within the current window.
+	Used uint
+}
+
+// Quota executes actions as long as the allowance of the current window is
+// not used up, returning FilledError otherwise. FilledError.Reset carries the
+// time at which the next action can be executed. Every call to Execute uses
+// up the allowance once, regardless of whether the action succeeds.
+type Quota struct {
+	bud uint
+	cal Calendar
+	itv time.Duration
+	key string
+	loc *time.Location
+	sto StateStore
+}
+
+func NewQuota(config QuotaConfig) *Quota {
+	if config.Budget == 0 {
+		panic(fmt.Sprintf("%T.Budget must not be empty", config))
+	}
+	if config.Interval == 0 {
+		config.Interval = 24 * time.Hour
+	}
+	if config.Key == "" {
+		config.Key = "quota"
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+	if config.Store == nil {
+		config.Store = &memoryStore{}
+	}
+
+	q := &Quota{
+		bud: config.Budget,
+		cal: config.Calendar,
+		itv: config.Interval,
+		key: config.Key,
+		loc: config.Location,
+		sto: config.Store,
+	}
+
+	return q
+}
+
+// Check returns the usage of the quota without using up any allowance. The
+// returned error is FilledError if the next action would be rejected.
+func (q *Quota) Check() (QuotaUsage, error) {
+	var use QuotaUsage
+
+	err := q.sto.Update(q.key, func(sta *State) error {
+		now := time.Now().In(q.loc)
+
+		cur, end := q.advance(sta, now)
+
+		use = q.usage(cur, now, end)
+
+		if use.Remaining == 0 {
+			return q.filled(use.Reset, now)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return use, tracer.Mask(err)
+	}
+
+	return use, nil
+}
+
+func (q *Quota) Execute(act func() error) error {
+	err := q.Wrapper(act)()
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+func (q *Quota) Wrapper(act func() error) func() error {
+	return func() error {
+		err := q.sto.Update(q.key, func(sta *State) error {
+			now := time.Now().In(q.loc)
+
+			cur, end := q.advance(sta, now)
+
+			use := q.usage(cur, now, end)
+			if use.Remaining == 0 {
+				return q.filled(use.Reset, now)
+			}
+
+			cur.Count++
+
+			return nil
+		})
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		err = act()
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		return nil
+	}
+}
+
+// advance moves the given state forward to the window containing now, and
+// returns the counters of that window together with its end.
+func (q *Quota) advance(sta *State, now time.Time) (*QuotaState, time.Time) {
+	if sta.Quota == nil {
+		sta.Quota = &QuotaState{}
+	}
+
+	cur := sta.Quota
+
+	if q.cal != CalendarNone {
+		beg, end := q.cal.window(now)
+		if !cur.Start.Equal(beg) {
+			*cur = QuotaState{Start: beg}
+		}
+
+		return cur, end
+	}
+
+	beg := now.Truncate(q.itv)
+	if !cur.Start.Equal(beg) {
+		var pre uint
+		if cur.Start.Equal(beg.Add(-q.itv)) {
+			pre = cur.Count
+		}
+
+		*cur = QuotaState{Previous: pre, Start: beg}
+	}
+
+	return cur, beg.Add(q.itv)
+}
+
+// usage returns the usage of the given counters at the given time, for the
+// window ending at end.
+func (q *Quota) usage(cur *QuotaState, now time.Time, end time.Time) QuotaUsage {
+	var use float64
+	{
+		use = float64(cur.Count)
+	}
+
+	// The previous window only counts with the share it still overlaps the
+	// rolling window ending now.
+	if q.cal == CalendarNone && cur.Previous != 0 {
+		use += float64(cur.Previous) * (1 - float64(now.Sub(cur.Start))/float64(q.itv))
+	}
+
+	var rem uint
+	if use < float64(q.bud) {
+		rem = q.bud - uint(math.Ceil(use))
+	}
+
+	res := end
+	if rem == 0 {
+		res = q.reset(cur, end)
+	}
+
+	return QuotaUsage{
+		Remaining: rem,
+		Reset:     res,
+		Used:      uint(math.Ceil(use)),
+	}
+}
+
+// reset returns the earliest time at which the next action can be executed,
+// given that the allowance is used up for now.
+func (q *Quota) reset(cur *QuotaState, end time.Time) time.Time {
+	if q.cal != CalendarNone {
+		return end
+	}
+
+	// The next action fits once the weighted count drops to this limit.
+	lim := float64(q.bud - 1)
+
+	// Within the current window, the weighted count drops as the previous
+	// window slides out of the rolling window.
+	if cur.Previous != 0 && float64(cur.Count) <= lim {
+		sha := 1 - (lim-float64(cur.Count))/float64(cur.Previous)
+		return cur.Start.Add(time.Duration(math.Ceil(sha * float64(q.itv))))
+	}
+
+	// Within the next window, the count of the current window becomes the
+	// count of the previous window.
+	if float64(cur.Count) <= lim {
+		return end
+	}
+
+	sha := 1 - lim/float64(cur.Count)
+
+	return end.Add(time.Duration(math.Ceil(sha * float64(q.itv))))
+}
+
+func (q *Quota) filled(res time.Time, now time.Time) error {
+	del := res.Sub(now)
+
+	return tracer.Mask(&FilledError{
+		Anno:  fmt.Sprintf("quota of %d exhausted until %s", q.bud, res.Format(time.RFC3339)),
+		Delay: del,
+		Reset: res,
+	})
+}
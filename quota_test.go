@@ -0,0 +1,193 @@
+package breakr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Quota_Calendar(t *testing.T) {
+	testCases := []struct {
+		cal Calendar
+		now time.Time
+		sta time.Time
+		end time.Time
+	}{
+		// Case 0 ensures that hourly windows are aligned to the hour.
+		{
+			cal: CalendarHour,
+			now: time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC),
+			sta: time.Date(2024, 2, 29, 13, 0, 0, 0, time.UTC),
+			end: time.Date(2024, 2, 29, 14, 0, 0, 0, time.UTC),
+		},
+		// Case 1 ensures that daily windows are aligned to midnight.
+		{
+			cal: CalendarDay,
+			now: time.Date(2024, 2, 29, 13, 45, 10, 0, time.UTC),
+			sta: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			end: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		// Case 2 ensures that weekly windows start on Monday.
+		{
+			cal: CalendarWeek,
+			now: time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC),
+			sta: time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC),
+			end: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		},
+		// Case 3 ensures that monthly windows start on the first day of the
+		// month.
+		{
+			cal: CalendarMonth,
+			now: time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC),
+			sta: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			end: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			sta, end := tc.cal.window(tc.now)
+
+			if !sta.Equal(tc.sta) {
+				t.Fatalf("sta\n\n%s\n", cmp.Diff(tc.sta, sta))
+			}
+			if !end.Equal(tc.end) {
+				t.Fatalf("end\n\n%s\n", cmp.Diff(tc.end, end))
+			}
+		})
+	}
+}
+
+func Test_Quota_Rolling(t *testing.T) {
+	var sta time.Time
+	{
+		sta = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		cur QuotaState
+		now time.Time
+		rem uint
+		res time.Time
+	}{
+		// Case 0 ensures that the previous window counts with the share it
+		// still overlaps the rolling window.
+		{
+			cur: QuotaState{Count: 2, Previous: 10, Start: sta},
+			now: sta.Add(30 * time.Minute),
+			rem: 3,
+			res: sta.Add(time.Hour),
+		},
+		// Case 1 ensures that the reset time accounts for the previous window
+		// sliding out of the rolling window.
+		{
+			cur: QuotaState{Count: 5, Previous: 10, Start: sta},
+			now: sta.Add(30 * time.Minute),
+			rem: 0,
+			res: sta.Add(36 * time.Minute),
+		},
+		// Case 2 ensures that the reset time accounts for the current window
+		// sliding out of the rolling window.
+		{
+			cur: QuotaState{Count: 10, Start: sta},
+			now: sta.Add(30 * time.Minute),
+			rem: 0,
+			res: sta.Add(66 * time.Minute),
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var q *Quota
+			{
+				q = NewQuota(QuotaConfig{
+					Budget:   10,
+					Interval: time.Hour,
+				})
+			}
+
+			cur := tc.cur
+			use := q.usage(&cur, tc.now, tc.cur.Start.Add(time.Hour))
+
+			if use.Remaining != tc.rem {
+				t.Fatalf("rem\n\n%s\n", cmp.Diff(tc.rem, use.Remaining))
+			}
+			if !use.Reset.Equal(tc.res) {
+				t.Fatalf("res\n\n%s\n", cmp.Diff(tc.res, use.Reset))
+			}
+		})
+	}
+}
+
+func Test_Quota_Execute(t *testing.T) {
+	var dir string
+	{
+		dir = t.TempDir()
+	}
+
+	// Every quota uses its own store instance, which is equivalent to
+	// separate processes sharing the same directory.
+	newQuota := func() *Quota {
+		return NewQuota(QuotaConfig{
+			Budget:   3,
+			Calendar: CalendarDay,
+			Store:    NewFileStore(dir),
+		})
+	}
+
+	var cou counter
+
+	act := func() error {
+		cou.Inc()
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		err := newQuota().Execute(act)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var q *Quota
+	{
+		q = newQuota()
+	}
+
+	use, err := q.Check()
+	if !IsFilled(err) {
+		t.Fatalf("expected Filled, got %#v", err)
+	}
+	if use.Remaining != 0 || use.Used != 3 {
+		t.Fatalf("unexpected usage %#v", use)
+	}
+
+	err = q.Execute(act)
+	if !IsFilled(err) {
+		t.Fatalf("expected Filled, got %#v", err)
+	}
+
+	var fil *FilledError
+	if !errors.As(err, &fil) {
+		t.Fatalf("expected FilledError, got %#v", err)
+	}
+
+	var res time.Time
+	{
+		_, res = CalendarDay.window(time.Now().UTC())
+	}
+
+	if !fil.Reset.Equal(res) {
+		t.Fatalf("res\n\n%s\n", cmp.Diff(res, fil.Reset))
+	}
+	if fil.Delay <= 0 || fil.Delay > 24*time.Hour {
+		t.Fatalf("unexpected delay %s", fil.Delay)
+	}
+
+	if cou.Cou() != 3 {
+		t.Fatalf("cou\n\n%s\n", cmp.Diff(uint(3), cou.Cou()))
+	}
+}
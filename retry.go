@@ -0,0 +1,351 @@
+package breakr
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// retry is the execution loop shared by Failure.Policy, Success.Policy and
+// Breakr. Attempts are executed until the success budget is reached, while
+// failed attempts are retried within the failure budget. Attempts that timed
+// out are retried within the timeout budget if Timeout.Budget is configured,
+// and use up the failure budget otherwise. The execution loop stops once the
+// context of the execution is done or Timeout.Closer got closed, including
+// while cooling down.
+type retry struct {
+	fai Failure
+	log Logger
+	pol Poller
+	rec *recoverer
+	suc Success
+	tim Timeout
+}
+
+func (r *retry) Execute(act func() error) error {
+	err := r.Wrapper(act)()
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *retry) Wrapper(act func() error) func() error {
+	return wrapper([]Interface{r}, act)
+}
+
+func (r *retry) apply(ctx context.Context, act func(ctx context.Context) error) error {
+	return r.run(ctx, func(ctx context.Context, ano uint, cyc *cycle) error {
+		if cyc == nil {
+			return act(ctx)
+		}
+
+		return r.attempt(ctx, act, ano, cyc, true)
+	})
+}
+
+// run executes the execution loop, calling att for every attempt. att is
+// expected to execute attempts synchronously if cyc is nil, which is the case
+// if attempts can neither time out nor be interrupted, and to use
+// retry.attempt otherwise. Leaving the decision to att allows callers to only
+// allocate the action of asynchronous attempts on the heap.
+func (r *retry) run(ctx context.Context, att func(ctx context.Context, ano uint, cyc *cycle) error) error {
+	var ano uint
+	var fco uint
+	var sco uint
+	var tco uint
+
+	var pro *progress
+	{
+		pro, _ = ctx.Value(progressKey).(*progress)
+	}
+
+	if r.rec != nil {
+		ctx = context.WithValue(ctx, recoverKey, r.rec)
+	}
+
+	// cyc is only allocated once the first asynchronous attempt or cooldown
+	// needs it.
+	var cyc *cycle
+	defer func() {
+		cyc.stop()
+	}()
+
+	for {
+		err := r.interrupted(ctx)
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		// Attempts are skipped entirely if the remaining time of the deadline
+		// of the execution is not worth trying anymore.
+		dea, ok := ctx.Deadline()
+		if ok && r.tim.Floor != -1 && time.Until(dea) < r.tim.Floor {
+			return tracer.Mask(Passed)
+		}
+
+		ano++
+
+		pro.update(CallRunning, ano)
+
+		var cur *cycle
+		if !r.synchronous(ctx) {
+			if cyc == nil {
+				cyc = &cycle{}
+			}
+
+			cur = cyc
+		}
+
+		err = att(ctx, ano, cur)
+
+		{
+			err := r.interrupted(ctx)
+			if err != nil {
+				return tracer.Mask(err)
+			}
+		}
+
+		if err == nil {
+			sco++
+			if sco >= r.suc.Budget {
+				return nil
+			}
+
+			err := r.cooldown(ctx, pro, ano, &cyc, r.suc.Cooler)
+			if err != nil {
+				return tracer.Mask(err)
+			}
+
+			continue
+		}
+
+		if r.tim.Budget != 0 && IsPassed(err) {
+			r.log.emit(EventTimeout, ano, pro.start(), nil)
+
+			if r.suc.Consecutive {
+				sco = 0
+			}
+
+			tco++
+			if tco >= r.tim.Budget {
+				return tracer.Mask(err)
+			}
+
+			err := r.cooldown(ctx, pro, ano, &cyc, r.tim.Cooler)
+			if err != nil {
+				return tracer.Mask(err)
+			}
+
+			continue
+		}
+
+		if r.fai.Classify != nil {
+			cla := r.fai.Classify(err)
+			if cla != nil {
+				err = cla
+			}
+		}
+
+		if IsCancel(err) || IsClosed(err) || IsFilled(err) || IsOversized(err) {
+			return tracer.Mask(err)
+		}
+
+		if IsPending(err) {
+			err := r.cooldown(ctx, pro, ano, &cyc, r.pol.Interval)
+			if err != nil {
+				return tracer.Mask(err)
+			}
+
+			continue
+		}
+
+		if IsRepeat(err) {
+			continue
+		}
+
+		if r.suc.Consecutive {
+			sco = 0
+		}
+
+		fco++
+		if fco >= r.fai.Budget {
+			return tracer.Mask(err)
+		}
+
+		r.log.emit(EventRetry, ano, pro.start(), err)
+
+		{
+			err := r.cooldown(ctx, pro, ano, &cyc, r.fai.Cooler)
+			if err != nil {
+				return tracer.Mask(err)
+			}
+		}
+	}
+}
+
+// attempt executes act in its own goroutine and waits for its result, unless
+// the attempt timed out or the execution loop got interrupted, in which case
+// the context of the attempt is cancelled. Passed is returned if the attempt
+// timed out, or if it failed because its own deadline expired. The context of
+// the attempt only carries the deadline of the attempt if dln is true, so
+// that callers not reading the deadline do not pay for it.
+func (r *retry) attempt(ctx context.Context, act func(ctx context.Context) error, ano uint, cyc *cycle, dln bool) error {
+	var att context.Context
+	var can context.CancelFunc
+	if dln && r.tim.Action != -1 {
+		att, can = context.WithTimeout(ctx, r.tim.Action)
+	} else {
+		att, can = context.WithCancel(ctx)
+	}
+
+	defer can()
+
+	// The buffer guarantees that no attempt ever blocks on sending its
+	// result, since every attempt that timed out uses up the timeout budget.
+	if cyc.res == nil {
+		cyc.res = make(chan result, r.tim.Budget+1)
+	}
+
+	go func(res chan<- result) {
+		res <- result{ano: ano, err: act(att)}
+	}(cyc.res)
+
+	// The attempt timeout is either tracked by the deadline of the attempt
+	// context, or by the shared timer.
+	var exp <-chan struct{}
+	var tim <-chan time.Time
+	if r.tim.Action != -1 {
+		if dln {
+			exp = att.Done()
+		} else {
+			tim = cyc.wait(r.tim.Action)
+			defer cyc.stop()
+		}
+	}
+
+	for {
+		select {
+		case res := <-cyc.res:
+			// Results of attempts that timed out before are discarded.
+			if res.ano != ano {
+				continue
+			}
+
+			if res.err != nil && ctx.Err() == nil && errors.Is(att.Err(), context.DeadlineExceeded) {
+				return tracer.Mask(Passed)
+			}
+
+			return res.err
+		case <-r.tim.Closer:
+			return tracer.Mask(Closed)
+		case <-ctx.Done():
+			return interrupted(ctx)
+		case <-exp:
+			if ctx.Err() != nil {
+				return interrupted(ctx)
+			}
+
+			return tracer.Mask(Passed)
+		case <-tim:
+			return tracer.Mask(Passed)
+		}
+	}
+}
+
+// synchronous returns whether attempts can neither time out nor be
+// interrupted, in which case they are executed on the calling goroutine.
+func (r *retry) synchronous(ctx context.Context) bool {
+	return r.tim.Action == -1 && r.tim.Closer == nil && ctx.Done() == nil
+}
+
+// cooldown waits for the given duration, unless the execution loop gets
+// interrupted in the meantime.
+func (r *retry) cooldown(ctx context.Context, pro *progress, ano uint, cyc **cycle, dur time.Duration) error {
+	if dur == -1 {
+		return nil
+	}
+
+	r.log.emit(EventCooldown, ano, pro.start(), nil, slog.Duration("delay", dur))
+
+	pro.update(CallCooldown, ano)
+
+	if *cyc == nil {
+		*cyc = &cycle{}
+	}
+
+	tim := (*cyc).wait(dur)
+	defer (*cyc).stop()
+
+	select {
+	case <-tim:
+		return nil
+	case <-r.tim.Closer:
+		return tracer.Mask(Closed)
+	case <-ctx.Done():
+		return interrupted(ctx)
+	}
+}
+
+// interrupted returns the error of the execution loop being interrupted, if
+// any.
+func (r *retry) interrupted(ctx context.Context) error {
+	select {
+	case <-r.tim.Closer:
+		return tracer.Mask(Closed)
+	default:
+	}
+
+	if ctx.Err() != nil {
+		return interrupted(ctx)
+	}
+
+	return nil
+}
+
+// result is the result of an attempt executed asynchronously.
+type result struct {
+	ano uint
+	err error
+}
+
+// cycle is the state of a single execution loop shared by its asynchronous
+// attempts and cooldowns.
+type cycle struct {
+	// res receives the results of attempts executed asynchronously.
+	res chan result
+	// tmr is the timer shared by attempt timeouts and cooldowns, which never
+	// overlap. It is only created once it is needed and reused afterwards.
+	tmr *time.Timer
+}
+
+// wait returns the channel of the shared timer firing after the given
+// duration.
+func (c *cycle) wait(dur time.Duration) <-chan time.Time {
+	if c.tmr == nil {
+		c.tmr = time.NewTimer(dur)
+	} else {
+		c.tmr.Reset(dur)
+	}
+
+	return c.tmr.C
+}
+
+// stop stops the shared timer, if any, and drains its channel, so that it can
+// be reused.
+func (c *cycle) stop() {
+	if c == nil || c.tmr == nil {
+		return
+	}
+
+	if !c.tmr.Stop() {
+		select {
+		case <-c.tmr.C:
+		default:
+		}
+	}
+}
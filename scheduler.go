@@ -0,0 +1,237 @@
+package breakr
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
+
+// Overlap defines how a Scheduler handles runs that are due while the
+// previous run is still in flight.
+type Overlap int
+
+const (
+	// OverlapSkip drops any run that is due while the previous run is still
+	// in flight. Skipped runs are reported to Observer.Run.
+	OverlapSkip Overlap = iota
+	// OverlapQueue executes a run that is due while the previous run is still
+	// in flight as soon as the previous run completed. At most one run is
+	// queued at any given time.
+	OverlapQueue
+)
+
+// Run describes the outcome of a single run of a Scheduler.
+type Run struct {
+	// Duration is the time it took to execute the run.
+	Duration time.Duration
+	// Error is the error returned by Interface.Execute for the run.
+	Error error
+	// Skipped is true if the run was dropped because the previous run was
+	// still in flight. Skipped runs are never executed.
+	Skipped bool
+	// Start is the time at which the run started or was skipped.
+	Start time.Time
+}
+
+type SchedulerConfig struct {
+	// Action is the action executed on every run.
+	Action func() error
+	// Breakr is the breaker every run is executed through. Defaults to
+	// Default().
+	Breakr Interface
+	// Interval is the fixed time between the scheduled start of two runs.
+	// Interval is ignored if Next is configured. Defaults to 1 minute.
+	Interval time.Duration
+	// Jitter is the optional maximum of random delay added to the scheduled
+	// start of every run, in order to spread the load of many schedulers
+	// started at the same time. Defaults to -1. Disabled with -1.
+	Jitter time.Duration
+	// Next is the optional function returning the scheduled start of the next
+	// run, given the scheduled start of the previous run, or the time the
+	// Scheduler got started. Next allows to plug in calendar based schedules
+	// like cron expressions.
+	Next func(las time.Time) time.Time
+	// Observer is the optional set of hooks receiving the outcome of every run
+	// using Observer.Run.
+	Observer Observer
+	// Overlap defines how runs are handled that are due while the previous run
+	// is still in flight. Defaults to OverlapSkip.
+	Overlap Overlap
+}
+
+// Scheduler executes an action periodically, while every run is executed
+// through the configured breaker.
+type Scheduler struct {
+	act func() error
+	bre Interface
+	don chan struct{}
+	jit time.Duration
+	mut sync.Mutex
+	nxt func(las time.Time) time.Time
+	obs Observer
+	ove Overlap
+	que bool
+	run bool
+	sto bool
+	wai sync.WaitGroup
+}
+
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	if config.Action == nil {
+		panic(fmt.Sprintf("%T.Action must not be empty", config))
+	}
+	if config.Breakr == nil {
+		config.Breakr = Default()
+	}
+	if config.Interval == 0 {
+		config.Interval = 1 * time.Minute
+	}
+	if config.Jitter == 0 {
+		config.Jitter = -1
+	}
+	if config.Next == nil {
+		config.Next = func(las time.Time) time.Time { return las.Add(config.Interval) }
+	}
+
+	s := &Scheduler{
+		act: config.Action,
+		bre: config.Breakr,
+		don: make(chan struct{}),
+		jit: config.Jitter,
+		nxt: config.Next,
+		obs: config.Observer,
+		ove: config.Overlap,
+	}
+
+	return s
+}
+
+// Start begins scheduling runs in the background. The first run is scheduled
+// relative to the time Start is called.
+func (s *Scheduler) Start() {
+	go s.loop()
+}
+
+// Stop prevents any further runs from being scheduled and waits for runs in
+// flight to complete. Queued runs are dropped. Stop returns the error of the
+// given context if it expires before all runs in flight completed.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	{
+		s.mut.Lock()
+		if !s.sto {
+			s.sto = true
+			close(s.don)
+		}
+		s.que = false
+		s.mut.Unlock()
+	}
+
+	fin := make(chan struct{})
+
+	go func() {
+		s.wai.Wait()
+		close(fin)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return tracer.Mask(ctx.Err())
+	case <-fin:
+	}
+
+	return nil
+}
+
+func (s *Scheduler) execute() {
+	defer s.wai.Done()
+
+	for {
+		var sta time.Time
+		{
+			sta = time.Now()
+		}
+
+		err := s.bre.Execute(s.act)
+
+		if s.obs.Run != nil {
+			s.obs.Run(Run{Duration: time.Since(sta), Error: err, Start: sta})
+		}
+
+		{
+			s.mut.Lock()
+			if s.que && !s.sto {
+				s.que = false
+				s.mut.Unlock()
+				continue
+			}
+			s.run = false
+			s.mut.Unlock()
+		}
+
+		return
+	}
+}
+
+func (s *Scheduler) loop() {
+	var las time.Time
+	{
+		las = time.Now()
+	}
+
+	for {
+		{
+			las = s.nxt(las)
+		}
+
+		var del time.Duration
+		{
+			del = time.Until(las)
+		}
+
+		if s.jit > 0 {
+			del += time.Duration(rand.Int63n(int64(s.jit)))
+		}
+
+		tim := time.NewTimer(del)
+
+		select {
+		case <-s.don:
+			tim.Stop()
+			return
+		case <-tim.C:
+		}
+
+		s.trigger()
+	}
+}
+
+func (s *Scheduler) trigger() {
+	var ski bool
+	{
+		s.mut.Lock()
+		if s.sto {
+			s.mut.Unlock()
+			return
+		}
+		if s.run && s.ove == OverlapQueue {
+			s.que = true
+		}
+		if s.run && s.ove == OverlapSkip {
+			ski = true
+		}
+		if !s.run {
+			s.run = true
+			s.wai.Add(1)
+			go s.execute()
+		}
+		s.mut.Unlock()
+	}
+
+	if ski && s.obs.Run != nil {
+		s.obs.Run(Run{Skipped: true, Start: time.Now()})
+	}
+}
@@ -0,0 +1,129 @@
+package breakr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_Scheduler_Overlap(t *testing.T) {
+	testCases := []struct {
+		ove Overlap
+		ski bool
+	}{
+		// Case 0 ensures that runs due while the previous run is still in
+		// flight are skipped.
+		{
+			ove: OverlapSkip,
+			ski: true,
+		},
+		// Case 1 ensures that runs due while the previous run is still in
+		// flight are queued.
+		{
+			ove: OverlapQueue,
+			ski: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var cou *counter
+			{
+				cou = &counter{}
+			}
+
+			var mut sync.Mutex
+			var run []Run
+
+			var s *Scheduler
+			{
+				s = NewScheduler(SchedulerConfig{
+					Action: func() error {
+						cou.Inc()
+						defer cou.Dec()
+						time.Sleep(25 * time.Millisecond)
+						return nil
+					},
+					Interval: 10 * time.Millisecond,
+					Observer: Observer{
+						Run: func(r Run) {
+							mut.Lock()
+							run = append(run, r)
+							mut.Unlock()
+						},
+					},
+					Overlap: tc.ove,
+				})
+			}
+
+			s.Start()
+			time.Sleep(100 * time.Millisecond)
+
+			err := s.Stop(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cou.Max() != 1 {
+				t.Fatalf("expected runs not to overlap")
+			}
+			if cou.Cou() != 0 {
+				t.Fatalf("expected runs in flight to be drained")
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			var exe int
+			var ski int
+			for _, r := range run {
+				if r.Skipped {
+					ski++
+				} else {
+					exe++
+				}
+			}
+
+			if exe < 2 {
+				t.Fatalf("expected at least 2 runs got %d", exe)
+			}
+			if tc.ski && ski == 0 {
+				t.Fatalf("expected runs to be skipped")
+			}
+			if !tc.ski && ski != 0 {
+				t.Fatalf("expected runs not to be skipped")
+			}
+		})
+	}
+}
+
+func Test_Scheduler_Stop(t *testing.T) {
+	var s *Scheduler
+	{
+		s = NewScheduler(SchedulerConfig{
+			Action: func() error {
+				time.Sleep(200 * time.Millisecond)
+				return nil
+			},
+			Interval: 10 * time.Millisecond,
+		})
+	}
+
+	s.Start()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, can := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer can()
+
+	err := s.Stop(ctx)
+	if err == nil {
+		t.Fatalf("expected context error")
+	}
+
+	err = s.Stop(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
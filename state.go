@@ -0,0 +1,76 @@
+package breakr
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a breaker that can be persisted across process
+// restarts using a StateStore.
+type State struct {
+	// Limiter contains the admission times of the actions executed within the
+	// longest window defined by Limiter.Cooler and Limiter.Windows.
+	Limiter []time.Time `json:"limiter,omitempty"`
+	// Quota contains the counters of the window tracked by Quota.
+	Quota *QuotaState `json:"quota,omitempty"`
+}
+
+// QuotaState contains the counters of the window tracked by Quota.
+type QuotaState struct {
+	// Count is the amount of actions admitted within the current window.
+	Count uint `json:"count"`
+	// Previous is the amount of actions admitted within the window preceding
+	// the current window. Previous is only tracked for rolling windows.
+	Previous uint `json:"previous,omitempty"`
+	// Start is the start of the current window.
+	Start time.Time `json:"start"`
+}
+
+// StateStore persists the state of breakers, so that processes restarting,
+// or processes running in parallel on the same host, share the state of
+// their breakers.
+type StateStore interface {
+	// Update reads the state stored under the given key, executes fn with it
+	// and stores the state as modified by fn. The state is not stored if fn
+	// returns an error, in which case the error of fn is returned. Update
+	// must be atomic, so that concurrent updates of the same key never
+	// overwrite each other.
+	Update(key string, fn func(sta *State) error) error
+}
+
+// memoryStore is the StateStore keeping the state of breakers in memory,
+// which is used by Quota if no StateStore is configured. Limiters without a
+// StateStore do not use it, but track their admission times in memory
+// themselves.
+type memoryStore struct {
+	mut sync.Mutex
+	sta map[string]*State
+}
+
+func (m *memoryStore) Update(key string, fn func(sta *State) error) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.sta == nil {
+		m.sta = map[string]*State{}
+	}
+
+	var cur State
+	if s, ok := m.sta[key]; ok {
+		cur = *s
+		if s.Quota != nil {
+			q := *s.Quota
+			cur.Quota = &q
+		}
+		cur.Limiter = append([]time.Time(nil), s.Limiter...)
+	}
+
+	err := fn(&cur)
+	if err != nil {
+		return err
+	}
+
+	m.sta[key] = &cur
+
+	return nil
+}
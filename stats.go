@@ -0,0 +1,44 @@
+package breakr
+
+import "sync/atomic"
+
+// Stats is a snapshot of the counters tracked by a breaker instance over its
+// entire lifetime.
+type Stats struct {
+	// Execute is the amount of calls to Breakr.Execute and Breakr.Wrapper.
+	Execute uint64
+	// Failure is the amount of calls that returned an error, regardless of
+	// whether a fallback got executed afterwards.
+	Failure uint64
+	// Fallback is the amount of calls that handed control over to the fallback
+	// provided to Breakr.ExecuteWithFallback.
+	Fallback uint64
+	// Success is the amount of calls that returned without error.
+	Success uint64
+}
+
+type stats struct {
+	exe atomic.Uint64
+	fai atomic.Uint64
+	fal atomic.Uint64
+	suc atomic.Uint64
+}
+
+func (s *stats) Record(err error) {
+	s.exe.Add(1)
+
+	if err != nil {
+		s.fai.Add(1)
+	} else {
+		s.suc.Add(1)
+	}
+}
+
+func (s *stats) Snapshot() Stats {
+	return Stats{
+		Execute:  s.exe.Load(),
+		Failure:  s.fai.Load(),
+		Fallback: s.fal.Load(),
+		Success:  s.suc.Load(),
+	}
+}
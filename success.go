@@ -1,9 +1,44 @@
 package breakr
 
+import "time"
+
 type Success struct {
 	// Budget is the required amount of successful executions of the provided
 	// action. Breakr.Execute guarantees act to be executed Budget times without
 	// any error returned, unless the configured signal channel got closed or the
 	// configured timeout passed. Defaults to 1.
 	Budget uint
+	// Consecutive defines whether the successful executions required by Budget
+	// have to happen in a row. If set to true, any execution failing or timing
+	// out resets the amount of successful executions counted so far. Given a
+	// Budget of 3, the sequence success, failure, success, failure, success
+	// would then not be sufficient. Defaults to false.
+	Consecutive bool
+	// Cooler is the optional time to wait after any successful execution
+	// before executing the provided action again. Only takes effect if
+	// Budget > 1. Defaults to -1. Disabled with -1.
+	Cooler time.Duration
+}
+
+// Policy returns the success policy of the success budget, executing the
+// provided action until it succeeded Budget times. Any error other than Repeat
+// and Pending is returned immediately. Composing the success policy inside of
+// a retry policy therefore requires the successful executions to happen in a
+// row.
+func (s Success) Policy() Interface {
+	if s.Budget == 0 {
+		s.Budget = 1
+	}
+	if s.Cooler == 0 {
+		s.Cooler = -1
+	}
+
+	r := &retry{
+		fai: Failure{Budget: 1, Cooler: -1},
+		pol: Poller{Interval: -1},
+		suc: s,
+		tim: Timeout{Action: -1, Cooler: -1, Floor: -1},
+	}
+
+	return r
 }
@@ -0,0 +1,93 @@
+package breakr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Breakr_Success_Consecutive(t *testing.T) {
+	testCases := []struct {
+		con bool
+		cou int
+	}{
+		// Case 0 ensures that successful executions are counted cumulatively
+		// by default.
+		{
+			con: false,
+			cou: 5,
+		},
+		// Case 1 ensures that failed executions reset the successful
+		// executions counted so far.
+		{
+			con: true,
+			cou: 7,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var b *Breakr
+			{
+				b = New(Config{
+					Failure: Failure{
+						Budget: 5,
+						Cooler: -1,
+					},
+					Success: Success{
+						Budget:      3,
+						Consecutive: tc.con,
+					},
+				})
+			}
+
+			var cou int
+
+			err := b.Execute(func() error {
+				cou++
+
+				// success, failure, success, failure, success, success, success
+				if cou == 2 || cou == 4 {
+					return fmt.Errorf("test error")
+				}
+
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cou != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, cou))
+			}
+		})
+	}
+}
+
+func Test_Breakr_Success_Cooler(t *testing.T) {
+	var b *Breakr
+	{
+		b = New(Config{
+			Success: Success{
+				Budget: 3,
+				Cooler: 20 * time.Millisecond,
+			},
+		})
+	}
+
+	var sta time.Time
+	{
+		sta = time.Now()
+	}
+
+	err := b.Execute(func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Since(sta) < 40*time.Millisecond {
+		t.Fatalf("expected successful executions to be paced")
+	}
+}
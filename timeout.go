@@ -1,10 +1,23 @@
 package breakr
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xh3b4sd/tracer"
+)
 
 type Timeout struct {
 	// Action is the amount of time after which the provided action will not be
-	// executed anymore. Defaults to 3 seconds.
+	// executed anymore. The timeout of every attempt is further bound to the
+	// remaining time of Global, so that the last attempt never outlives the
+	// global timeout. Attempts that timed out are not waited for, and their
+	// results are discarded once they arrive, even if an attempt succeeded or
+	// returned Cancel after its timeout. Disabling Action, Global and Closer
+	// causes attempts to be executed synchronously on the calling goroutine,
+	// which is the cheapest way to wrap hot paths. Defaults to 3 seconds.
+	// Disabled with -1.
 	Action time.Duration
 	// Budget is the amount of attempts that can be used up when consuming the
 	// timeout budget. The configured operation is being executed until Timeout
@@ -16,9 +29,102 @@ type Timeout struct {
 	// Cooler is the optinal time to wait after any given timeout. Only takes
 	// effect if Budget > 1. Defaults to -1. Disabled with -1.
 	Cooler time.Duration
+	// Floor is the minimum remaining time of Global required for another
+	// attempt to be made. If less time remains, the attempt is skipped and
+	// Passed is returned immediately. Only takes effect if Global is
+	// configured, or if the context provided to Breakr.ExecuteContext carries
+	// a deadline. Defaults to -1. Disabled with -1.
+	Floor time.Duration
 	// Global is the amount of time after which the breaker instance stops
 	// executing the provided action and returns Passed. This is a hard global
 	// timeout for each call to Breakr.Execute, applying regardless of any other
 	// budget configurations. Defaults to -1. Disabled with -1.
 	Global time.Duration
 }
+
+// Policy returns the timeout policy of the timeout configuration, returning
+// Passed if the provided action does not complete within Action, and Closed
+// if Closer got closed while the provided action executes. Budget, Cooler and
+// Global are not used by the timeout policy. Composing the timeout policy
+// outside of a retry policy bounds all attempts, while composing it inside of
+// a retry policy bounds every attempt. Actions that timed out are not waited
+// for, and their results are discarded.
+func (t Timeout) Policy() Interface {
+	if t.Action == 0 {
+		t.Action = 3 * time.Second
+	}
+
+	return &timeoutPolicy{tim: t}
+}
+
+type timeoutPolicy struct {
+	tim Timeout
+}
+
+func (p *timeoutPolicy) Execute(act func() error) error {
+	err := p.Wrapper(act)()
+	if err != nil {
+		return tracer.Mask(err)
+	}
+
+	return nil
+}
+
+func (p *timeoutPolicy) Wrapper(act func() error) func() error {
+	return wrapper([]Interface{p}, act)
+}
+
+// apply executes act in its own goroutine, using a context that expires
+// after Action and that is cancelled once apply returned. Actions that cannot
+// be interrupted, because neither Action, Closer nor a cancellable context is
+// given, are executed synchronously.
+func (p *timeoutPolicy) apply(ctx context.Context, act func(ctx context.Context) error) error {
+	if p.tim.Action == -1 && p.tim.Closer == nil && ctx.Done() == nil {
+		return act(ctx)
+	}
+
+	var par context.Context
+	{
+		par = ctx
+	}
+
+	var can context.CancelFunc
+	if p.tim.Action == -1 {
+		ctx, can = context.WithCancel(ctx)
+	} else {
+		ctx, can = context.WithTimeout(ctx, p.tim.Action)
+	}
+
+	defer can()
+
+	// The buffer guarantees that actions which are not waited for anymore do
+	// not block on sending their result.
+	res := make(chan error, 1)
+
+	go func() {
+		res <- act(ctx)
+	}()
+
+	select {
+	case err := <-res:
+		// Actions failing because their own deadline expired are considered
+		// to have timed out.
+		if err != nil && par.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return tracer.Mask(Passed)
+		}
+
+		if err != nil {
+			return tracer.Mask(err)
+		}
+
+		return nil
+	case <-p.tim.Closer:
+		return tracer.Mask(Closed)
+	case <-ctx.Done():
+		if par.Err() != nil {
+			return interrupted(par)
+		}
+
+		return tracer.Mask(Passed)
+	}
+}
@@ -0,0 +1,199 @@
+package breakr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Breakr_Timeout_Deadline(t *testing.T) {
+	testCases := []struct {
+		flo time.Duration
+		cou int
+	}{
+		// Case 0 ensures that the last attempt is bound to the remaining time
+		// of the global timeout.
+		{
+			flo: -1,
+			cou: 2,
+		},
+		// Case 1 ensures that attempts are skipped if the remaining time of
+		// the global timeout is below the configured floor.
+		{
+			flo: 100 * time.Millisecond,
+			cou: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			var b *Breakr
+			{
+				b = New(Config{
+					Timeout: Timeout{
+						Action: 200 * time.Millisecond,
+						Budget: 5,
+						Floor:  tc.flo,
+						Global: 250 * time.Millisecond,
+					},
+				})
+			}
+
+			var dea []time.Time
+			var mut sync.Mutex
+
+			var sta time.Time
+			{
+				sta = time.Now()
+			}
+
+			err := b.ExecuteContext(context.Background(), func(ctx context.Context) error {
+				{
+					d, _ := ctx.Deadline()
+					mut.Lock()
+					dea = append(dea, d)
+					mut.Unlock()
+				}
+
+				<-ctx.Done()
+
+				return ctx.Err()
+			})
+			if !IsPassed(err) {
+				t.Fatalf("expected error matcher to match")
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			if len(dea) != tc.cou {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.cou, len(dea)))
+			}
+
+			for _, d := range dea {
+				if d.After(sta.Add(260 * time.Millisecond)) {
+					t.Fatalf("expected attempt deadline to be bound to global timeout")
+				}
+			}
+		})
+	}
+}
+
+func Test_Breakr_Timeout_Context(t *testing.T) {
+	var b *Breakr
+	{
+		b = New(Config{
+			Failure: Failure{
+				Cooler: -1,
+			},
+		})
+	}
+
+	{
+		ctx, can := context.WithCancel(context.Background())
+
+		err := b.ExecuteContext(ctx, func(ctx context.Context) error {
+			can()
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+		if !IsClosed(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+	}
+
+	{
+		ctx, can := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer can()
+
+		err := b.ExecuteContext(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if !IsPassed(err) {
+			t.Fatalf("expected error matcher to match")
+		}
+	}
+}
+
+func Test_Breakr_Timeout_Cooler_Interrupt(t *testing.T) {
+	testCases := []struct {
+		clo bool
+		ctx bool
+		glo time.Duration
+		mat func(err error) bool
+	}{
+		// Case 0 ensures that closing the signal channel interrupts cooldowns.
+		{
+			clo: true,
+			glo: -1,
+			mat: IsClosed,
+		},
+		// Case 1 ensures that the global timeout interrupts cooldowns.
+		{
+			glo: 50 * time.Millisecond,
+			mat: IsPassed,
+		},
+		// Case 2 ensures that cancelling the caller's context interrupts
+		// cooldowns.
+		{
+			ctx: true,
+			glo: -1,
+			mat: IsClosed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%03d", i), func(t *testing.T) {
+			clo := make(chan struct{})
+
+			ctx, can := context.WithCancel(context.Background())
+			defer can()
+
+			var b *Breakr
+			{
+				b = New(Config{
+					Failure: Failure{
+						Cooler: 30 * time.Second,
+					},
+					Timeout: Timeout{
+						Closer: clo,
+						Global: tc.glo,
+					},
+				})
+			}
+
+			go func(cls bool, cnc bool) {
+				time.Sleep(50 * time.Millisecond)
+
+				if cls {
+					close(clo)
+				}
+				if cnc {
+					can()
+				}
+			}(tc.clo, tc.ctx)
+
+			var sta time.Time
+			{
+				sta = time.Now()
+			}
+
+			err := b.ExecuteContext(ctx, func(ctx context.Context) error {
+				return fmt.Errorf("test error")
+			})
+			if !tc.mat(err) {
+				t.Fatalf("expected error matcher to match")
+			}
+
+			if time.Since(sta) > time.Second {
+				t.Fatalf("expected cooldown to be interrupted")
+			}
+		})
+	}
+}